@@ -2,13 +2,27 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	authDelivery "github.com/DESOLATE17/Database-term-project/internal/pkg/auth/delivery/http"
+	authRepo "github.com/DESOLATE17/Database-term-project/internal/pkg/auth/repo"
+	authUsecase "github.com/DESOLATE17/Database-term-project/internal/pkg/auth/usecase"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/events"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/forum"
 	delivery "github.com/DESOLATE17/Database-term-project/internal/pkg/forum/delivery/http"
 	"github.com/DESOLATE17/Database-term-project/internal/pkg/forum/repo"
 	"github.com/DESOLATE17/Database-term-project/internal/pkg/forum/usecase"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/webhook"
+	webhookDelivery "github.com/DESOLATE17/Database-term-project/internal/pkg/webhook/delivery/http"
+	webhookRepo "github.com/DESOLATE17/Database-term-project/internal/pkg/webhook/repo"
+	webhookUsecase "github.com/DESOLATE17/Database-term-project/internal/pkg/webhook/usecase"
 	"github.com/gorilla/mux"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 )
 
 // sudo docker rm -f my_container
@@ -23,33 +37,101 @@ func main() {
 		log.Fatal("No connection to postgres", err)
 	}
 
+	secret := []byte(os.Getenv("AUTH_SECRET"))
+	if len(secret) == 0 {
+		log.Print("AUTH_SECRET is not set, tokens will not survive a restart")
+		secret = make([]byte, 32)
+		_, _ = rand.Read(secret)
+	}
+
+	aRepo := authRepo.NewRepoPostgres(pool)
+	aUsecase := authUsecase.NewAuthUsecase(aRepo, secret)
+	aHandler := authDelivery.NewAuthHandler(aUsecase)
+
+	hub := events.NewHub()
+	var publisher forum.Publisher = hub
+	if os.Getenv("EVENTS_PG_NOTIFY") != "" {
+		notifier := events.NewPgNotifier(pool, hub)
+		go notifier.Listen(context.Background())
+		publisher = notifier
+	}
+
+	wRepo := webhookRepo.NewRepoPostgres(pool)
+	wUsecase := webhookUsecase.NewWebhookUsecase(wRepo)
+	wHandler := webhookDelivery.NewWebhookHandler(wUsecase)
+	go webhookUsecase.NewDispatcher(wRepo, webhook.NewClient(10*time.Second)).Run(context.Background())
+	publisher = events.Fanout{publisher, wUsecase}
+
+	reactions := models.DefaultReactions
+	if env := os.Getenv("REACTIONS"); env != "" {
+		reactions = strings.Split(env, ",")
+	}
+
 	fRepo := repo.NewRepoPostgres(pool)
-	fUsecase := usecase.NewRepoUsecase(fRepo)
-	fHandler := delivery.NewForumHandler(fUsecase)
+	fUsecase := usecase.NewRepoUsecase(fRepo, publisher, reactions)
+	fHandler := delivery.NewForumHandler(fUsecase, hub)
 
-	forum := muxRoute.PathPrefix("/api").Subrouter()
+	api := muxRoute.PathPrefix("/api").Subrouter()
+	api.Use(aHandler.Middleware)
 	{
-		forum.HandleFunc("/user/{nickname}/create", fHandler.CreateUser).Methods(http.MethodPost)
-		forum.HandleFunc("/user/{nickname}/profile", fHandler.GetUser).Methods(http.MethodGet)
-		forum.HandleFunc("/user/{nickname}/profile", fHandler.ChangeUserInfo).Methods(http.MethodPost)
-
-		forum.HandleFunc("/forum/create", fHandler.CreateForum).Methods(http.MethodPost)
-		forum.HandleFunc("/forum/{slug}/details", fHandler.ForumInfo).Methods(http.MethodGet)
-		forum.HandleFunc("/forum/{slug}/create", fHandler.CreateForumThread).Methods(http.MethodPost)
-		forum.HandleFunc("/forum/{slug}/users", fHandler.GetUsersOfForum).Methods(http.MethodGet)
-		forum.HandleFunc("/forum/{slug}/threads", fHandler.GetForumThreads).Methods(http.MethodGet)
-
-		forum.HandleFunc("/post/{id}/details", fHandler.GetPostInfo).Methods(http.MethodGet)
-		forum.HandleFunc("/post/{id}/details", fHandler.UpdatePostInfo).Methods(http.MethodPost)
-
-		forum.HandleFunc("/service/clear", fHandler.GetClear).Methods(http.MethodPost)
-		forum.HandleFunc("/service/status", fHandler.GetStatus).Methods(http.MethodGet)
-
-		forum.HandleFunc("/thread/{slug_or_id}/create", fHandler.CreatePosts).Methods(http.MethodPost)
-		forum.HandleFunc("/thread/{slug_or_id}/details", fHandler.ThreadInfo).Methods(http.MethodGet)
-		forum.HandleFunc("/thread/{slug_or_id}/details", fHandler.UpdateThreadInfo).Methods(http.MethodPost)
-		forum.HandleFunc("/thread/{slug_or_id}/posts", fHandler.GetPostsOfThread).Methods(http.MethodGet)
-		forum.HandleFunc("/thread/{slug_or_id}/vote", fHandler.Vote).Methods(http.MethodPost)
+		api.HandleFunc("/auth/login", aHandler.Login).Methods(http.MethodPost)
+
+		api.HandleFunc("/user/{nickname}/create", fHandler.CreateUser).Methods(http.MethodPost)
+		api.HandleFunc("/user/{nickname}/profile", fHandler.GetUser).Methods(http.MethodGet)
+		api.HandleFunc("/user/{nickname}/profile", fHandler.ChangeUserInfo).Methods(http.MethodPost)
+		api.HandleFunc("/user/{nickname}/mentions", fHandler.GetMentions).Methods(http.MethodGet)
+		api.HandleFunc("/user/{nickname}/notifications", fHandler.GetNotifications).Methods(http.MethodGet)
+		api.HandleFunc("/user/{nickname}/notifications/read", fHandler.MarkAllNotificationsRead).Methods(http.MethodPost)
+		api.HandleFunc("/user/{nickname}/notifications/{id}/read", fHandler.MarkNotificationRead).Methods(http.MethodPost)
+
+		api.HandleFunc("/forum/create", fHandler.CreateForum).Methods(http.MethodPost)
+		api.HandleFunc("/forum/tree", fHandler.GetForumTree).Methods(http.MethodGet)
+		api.HandleFunc("/forum/{slug}/details", fHandler.ForumInfo).Methods(http.MethodGet)
+		api.HandleFunc("/forum/{slug}/details", fHandler.UpdateForum).Methods(http.MethodPost)
+		api.HandleFunc("/forum/{slug}/details", fHandler.DeleteForum).Methods(http.MethodDelete)
+		api.HandleFunc("/forum/{slug}/create", fHandler.CreateForumThread).Methods(http.MethodPost)
+		api.HandleFunc("/forum/{slug}/users", fHandler.GetUsersOfForum).Methods(http.MethodGet)
+		api.HandleFunc("/forum/{slug}/threads", fHandler.GetForumThreads).Methods(http.MethodGet)
+		api.HandleFunc("/forum/{slug}/moderators", fHandler.GetForumModerators).Methods(http.MethodGet)
+		api.HandleFunc("/forum/{slug}/moderators", fHandler.AddForumModerator).Methods(http.MethodPost)
+		api.HandleFunc("/forum/{slug}/moderators/{nickname}", fHandler.RemoveForumModerator).Methods(http.MethodDelete)
+		api.HandleFunc("/forum/{slug}/webhooks", wHandler.GetWebhooks).Methods(http.MethodGet)
+		api.HandleFunc("/forum/{slug}/webhooks", wHandler.CreateWebhook).Methods(http.MethodPost)
+		api.HandleFunc("/forum/{slug}/webhooks/{id}", wHandler.DeleteWebhook).Methods(http.MethodDelete)
+		api.HandleFunc("/forum/{slug}/webhooks/{id}/deliveries", wHandler.GetDeliveries).Methods(http.MethodGet)
+
+		api.HandleFunc("/post/{id}/details", fHandler.GetPostInfo).Methods(http.MethodGet)
+		api.HandleFunc("/post/{id}/details", fHandler.UpdatePostInfo).Methods(http.MethodPost)
+		api.HandleFunc("/post/{id}/details", fHandler.DeletePost).Methods(http.MethodDelete)
+		api.HandleFunc("/post/{id}/restore", fHandler.RestorePost).Methods(http.MethodPost)
+		api.HandleFunc("/post/{id}/revisions", fHandler.GetPostRevisions).Methods(http.MethodGet)
+		api.HandleFunc("/post/{id}/diff", fHandler.GetPostDiff).Methods(http.MethodGet)
+		api.HandleFunc("/post/{id}/split", fHandler.SplitThread).Methods(http.MethodPost)
+		api.HandleFunc("/post/{id}/vote", fHandler.VotePost).Methods(http.MethodPost)
+		api.HandleFunc("/post/{id}/reactions", fHandler.GetReactions).Methods(http.MethodGet)
+		api.HandleFunc("/post/{id}/reactions", fHandler.ToggleReaction).Methods(http.MethodPost)
+
+		api.HandleFunc("/search", fHandler.Search).Methods(http.MethodGet)
+		api.HandleFunc("/ws", fHandler.Gateway).Methods(http.MethodGet)
+
+		api.HandleFunc("/service/clear", fHandler.GetClear).Methods(http.MethodPost)
+		api.HandleFunc("/service/status", fHandler.GetStatus).Methods(http.MethodGet)
+
+		api.HandleFunc("/thread/{slug_or_id}/create", fHandler.CreatePosts).Methods(http.MethodPost)
+		api.HandleFunc("/thread/{slug_or_id}/details", fHandler.ThreadInfo).Methods(http.MethodGet)
+		api.HandleFunc("/thread/{slug_or_id}/details", fHandler.UpdateThreadInfo).Methods(http.MethodPost)
+		api.HandleFunc("/thread/{slug_or_id}/details", fHandler.DeleteThread).Methods(http.MethodDelete)
+		api.HandleFunc("/thread/{slug_or_id}/close", fHandler.CloseThread).Methods(http.MethodPost)
+		api.HandleFunc("/thread/{slug_or_id}/reopen", fHandler.ReopenThread).Methods(http.MethodPost)
+		api.HandleFunc("/thread/{slug_or_id}/pin", fHandler.PinThread).Methods(http.MethodPost)
+		api.HandleFunc("/thread/{slug_or_id}/unpin", fHandler.UnpinThread).Methods(http.MethodPost)
+		api.HandleFunc("/thread/{slug_or_id}/move", fHandler.MoveThread).Methods(http.MethodPost)
+		api.HandleFunc("/thread/{slug_or_id}/merge", fHandler.MergeThreads).Methods(http.MethodPost)
+		api.HandleFunc("/thread/{slug_or_id}/posts", fHandler.GetPostsOfThread).Methods(http.MethodGet)
+		api.HandleFunc("/thread/{slug_or_id}/vote", fHandler.Vote).Methods(http.MethodPost)
+		api.HandleFunc("/thread/{slug_or_id}/vote", fHandler.RetractVote).Methods(http.MethodDelete)
+		api.HandleFunc("/thread/{slug_or_id}/votes", fHandler.GetVotes).Methods(http.MethodGet)
+		api.HandleFunc("/thread/{slug_or_id}/stream", fHandler.StreamThread).Methods(http.MethodGet)
 	}
 
 	http.Handle("/", muxRoute)
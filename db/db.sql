@@ -5,7 +5,10 @@ CREATE UNLOGGED TABLE users
     Nickname CITEXT PRIMARY KEY,
     FullName TEXT NOT NULL,
     About    TEXT NOT NULL DEFAULT '',
-    Email    CITEXT UNIQUE
+    Email    CITEXT UNIQUE,
+    Password TEXT NOT NULL DEFAULT '',
+    -- admins are promoted by hand: UPDATE users SET Role = 'admin' WHERE Nickname = ...
+    Role     TEXT NOT NULL DEFAULT 'user'
 );
 
 CREATE UNLOGGED TABLE forum
@@ -14,7 +17,15 @@ CREATE UNLOGGED TABLE forum
     "user"  CITEXT,
     Slug    CITEXT PRIMARY KEY,
     Posts   INT DEFAULT 0,
-    Threads INT DEFAULT 0
+    Threads INT DEFAULT 0,
+    Parent  CITEXT REFERENCES "forum" (Slug)
+);
+
+CREATE UNLOGGED TABLE forum_moderator
+(
+    Slug     CITEXT NOT NULL REFERENCES "forum" (Slug),
+    Nickname CITEXT NOT NULL REFERENCES "users" (Nickname),
+    UNIQUE (Slug, Nickname)
 );
 
 CREATE UNLOGGED TABLE thread
@@ -25,8 +36,13 @@ CREATE UNLOGGED TABLE thread
     Forum   CITEXT REFERENCES "forum" (Slug),
     Message TEXT NOT NULL,
     Votes   INT                      DEFAULT 0,
-    Slug    CITEXT,
-    Created TIMESTAMP WITH TIME ZONE DEFAULT now()
+    Slug     CITEXT,
+    Created  TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    IsClosed BOOLEAN                  DEFAULT FALSE,
+    IsPinned BOOLEAN                  DEFAULT FALSE,
+    -- maintained by CreatePosts and the deleted posts trigger
+    Replies    INT                      DEFAULT 0,
+    LastPostAt TIMESTAMP WITH TIME ZONE DEFAULT now()
 );
 
 CREATE UNLOGGED TABLE post
@@ -40,16 +56,33 @@ CREATE UNLOGGED TABLE post
     Parent   INT                      DEFAULT 0,
     Thread   INT,
     Path     INTEGER[],
+    IsDeleted BOOLEAN                 DEFAULT FALSE,
+    Score    INT                      DEFAULT 0,
+    -- rendered Message, filled on the first ?format=html read
+    MessageHtml TEXT,
     FOREIGN KEY (thread) REFERENCES "thread" (id),
     FOREIGN KEY (author) REFERENCES "users" (nickname)
 );
 
+CREATE UNLOGGED TABLE post_revision
+(
+    Post    INT    NOT NULL REFERENCES "post" (Id),
+    Version INT    NOT NULL,
+    Message CITEXT NOT NULL,
+    Editor  CITEXT REFERENCES "users" (Nickname),
+    Created TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    PRIMARY KEY (Post, Version)
+);
+
 CREATE UNLOGGED TABLE vote
 (
     ID     SERIAL PRIMARY KEY,
     Author CITEXT REFERENCES "users" (Nickname),
-    Voice  INT NOT NULL,
+    Voice  INT NOT NULL CHECK (Voice IN (-1, 1)),
     Thread INT,
+    Voted  TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    -- a retracted vote is kept for the thread's history but counts for nothing
+    Retracted TIMESTAMP WITH TIME ZONE,
     FOREIGN KEY (thread) REFERENCES "thread" (id),
     UNIQUE (Author, Thread)
 );
@@ -67,14 +100,88 @@ CREATE UNLOGGED TABLE users_forum
     UNIQUE (Nickname, Slug)
 );
 
+CREATE UNLOGGED TABLE post_vote
+(
+    Post     INT    NOT NULL REFERENCES "post" (Id),
+    Nickname CITEXT NOT NULL REFERENCES "users" (Nickname),
+    Voice    INT    NOT NULL CHECK (Voice IN (-1, 1)),
+    Created  TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    PRIMARY KEY (Post, Nickname)
+);
+
+CREATE UNLOGGED TABLE post_reaction
+(
+    Post     INT    NOT NULL REFERENCES "post" (Id),
+    Nickname CITEXT NOT NULL REFERENCES "users" (Nickname),
+    Emoji    TEXT   NOT NULL,
+    Created  TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    Retracted TIMESTAMP WITH TIME ZONE,
+    -- one reaction per user per post; reacting with another emoji replaces it,
+    -- toggling it off only marks it retracted
+    PRIMARY KEY (Post, Nickname)
+);
+
+CREATE UNLOGGED TABLE post_mention
+(
+    Post     INT    NOT NULL REFERENCES "post" (Id),
+    Nickname CITEXT NOT NULL REFERENCES "users" (Nickname),
+    PRIMARY KEY (Post, Nickname)
+);
+
+CREATE UNLOGGED TABLE notification
+(
+    Id       SERIAL PRIMARY KEY,
+    Nickname CITEXT NOT NULL REFERENCES "users" (Nickname),
+    Kind     TEXT   NOT NULL,
+    Post     INT    NOT NULL REFERENCES "post" (Id),
+    Thread   INT    NOT NULL,
+    Forum    CITEXT NOT NULL,
+    Author   CITEXT NOT NULL,
+    IsRead   BOOLEAN                  DEFAULT FALSE,
+    Created  TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    -- a reply that also mentions the parent's author notifies once
+    UNIQUE (Nickname, Post)
+);
+
+CREATE UNLOGGED TABLE webhook
+(
+    Id      SERIAL PRIMARY KEY,
+    Forum   CITEXT NOT NULL REFERENCES "forum" (Slug) ON DELETE CASCADE,
+    Url     TEXT   NOT NULL,
+    Secret  TEXT   NOT NULL,
+    -- empty means every event type
+    Events  TEXT[] NOT NULL          DEFAULT '{}',
+    Created TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE UNLOGGED TABLE webhook_delivery
+(
+    Id           SERIAL PRIMARY KEY,
+    Webhook      INT  NOT NULL REFERENCES "webhook" (Id) ON DELETE CASCADE,
+    Event        TEXT NOT NULL,
+    Payload      TEXT NOT NULL,
+    Status       TEXT NOT NULL            DEFAULT 'pending',
+    Attempts     INT  NOT NULL            DEFAULT 0,
+    ResponseCode INT  NOT NULL            DEFAULT 0,
+    LastError    TEXT NOT NULL            DEFAULT '',
+    NextAttempt  TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    Created      TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    Delivered    TIMESTAMP WITH TIME ZONE
+);
+
 CREATE INDEX IF NOT EXISTS users_nickname_index ON users USING hash (nickname);
 CREATE INDEX IF NOT EXISTS users_email_index ON users USING hash (email);
 CREATE INDEX IF NOT EXISTS forum_slug_index ON forum USING hash (slug);
+CREATE INDEX IF NOT EXISTS forum_parent_index ON forum (parent);
 CREATE INDEX IF NOT EXISTS thread_slug_index ON thread USING hash (slug);
 CREATE INDEX IF NOT EXISTS thread_id_index ON thread USING hash (id);
 CREATE INDEX IF NOT EXISTS post_id_index ON post USING hash (id);
 
-CREATE INDEX IF NOT EXISTS thread_forum_date_index ON thread (forum, created);
+CREATE INDEX IF NOT EXISTS thread_forum_date_index ON thread (forum, created, id);
+CREATE INDEX IF NOT EXISTS thread_forum_pinned_index ON thread (forum) WHERE IsPinned;
+CREATE INDEX IF NOT EXISTS thread_forum_votes_index ON thread (forum, votes, id);
+CREATE INDEX IF NOT EXISTS thread_forum_activity_index ON thread (forum, lastpostat, id);
+CREATE INDEX IF NOT EXISTS thread_forum_replies_index ON thread (forum, replies, id);
 CREATE UNIQUE INDEX IF NOT EXISTS forum_users_index ON users_forum (slug, nickname);
 CREATE UNIQUE INDEX IF NOT EXISTS vote_index ON vote (Author, Thread);
 
@@ -83,6 +190,18 @@ CREATE INDEX IF NOT EXISTS post_thread_path_id_index ON post (thread, path, id);
 CREATE INDEX IF NOT EXISTS post_thread_id_path_parent_index ON post (thread, id, (path[1]), parent);
 CREATE INDEX IF NOT EXISTS post_path_index ON post ((path[1]));
 
+CREATE INDEX IF NOT EXISTS post_message_search_index ON post USING gin (to_tsvector('english', message::TEXT));
+CREATE INDEX IF NOT EXISTS thread_search_index ON thread USING gin (to_tsvector('english', title || ' ' || message));
+
+CREATE INDEX IF NOT EXISTS post_mention_nickname_index ON post_mention (nickname, post);
+
+CREATE INDEX IF NOT EXISTS notification_nickname_index ON notification (nickname, id);
+CREATE INDEX IF NOT EXISTS notification_unread_index ON notification (nickname, id) WHERE NOT IsRead;
+
+CREATE INDEX IF NOT EXISTS webhook_forum_index ON webhook (forum);
+CREATE INDEX IF NOT EXISTS webhook_delivery_pending_index ON webhook_delivery (NextAttempt) WHERE Status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_delivery_webhook_index ON webhook_delivery (webhook, id);
+
 CREATE UNLOGGED TABLE status
 (
     id      INT unique,
@@ -195,20 +314,27 @@ EXECUTE PROCEDURE updateCountOfThreads();
 CREATE OR REPLACE FUNCTION updateVotes() RETURNS TRIGGER AS
 $update_votes$
 BEGIN
+    -- retracted votes count as 0
     IF (TG_OP = 'UPDATE') THEN
-        IF OLD.Voice <> NEW.Voice THEN
-            UPDATE thread SET votes=(votes + NEW.Voice * 2) WHERE id = NEW.Thread;
+        IF OLD.Voice <> NEW.Voice OR (OLD.Retracted IS NULL) <> (NEW.Retracted IS NULL) THEN
+            UPDATE thread
+            SET votes=(votes + CASE WHEN NEW.Retracted IS NULL THEN NEW.Voice ELSE 0 END
+                             - CASE WHEN OLD.Retracted IS NULL THEN OLD.Voice ELSE 0 END)
+            WHERE id = NEW.Thread;
         END IF;
         return NEW;
     ELSIF (TG_OP = 'INSERT') THEN
-        UPDATE thread SET votes=(votes + NEW.voice) WHERE id = NEW.thread;
+        UPDATE thread SET votes=(votes + NEW.voice) WHERE id = NEW.thread AND NEW.Retracted IS NULL;
         return NEW;
+    ELSIF (TG_OP = 'DELETE') THEN
+        UPDATE thread SET votes=(votes - OLD.Voice) WHERE id = OLD.Thread AND OLD.Retracted IS NULL;
+        return OLD;
     END IF;
 end
 $update_votes$ LANGUAGE plpgsql;
 
 CREATE TRIGGER update_votes
-    BEFORE UPDATE OR INSERT
+    BEFORE UPDATE OR INSERT OR DELETE
     ON vote
     FOR EACH ROW
 EXECUTE PROCEDURE updateVotes();
@@ -241,5 +367,29 @@ CREATE TRIGGER update_path
     FOR EACH ROW
 EXECUTE PROCEDURE updatePath();
 
+CREATE OR REPLACE FUNCTION updateDeletedPosts() RETURNS TRIGGER AS
+$update_deleted_posts$
+DECLARE
+    delta INT;
+BEGIN
+    IF NEW.IsDeleted THEN
+        delta := -1;
+    ELSE
+        delta := 1;
+    END IF;
+    UPDATE forum SET Posts=Posts + delta WHERE forum.slug = NEW.forum;
+    UPDATE thread SET Replies=Replies + delta WHERE id = NEW.thread;
+    UPDATE status SET Posts=Posts + delta WHERE id = 1;
+    RETURN NEW;
+END
+$update_deleted_posts$ LANGUAGE plpgsql;
+
+CREATE TRIGGER update_deleted_posts
+    AFTER UPDATE OF IsDeleted
+    ON post
+    FOR EACH ROW
+    WHEN (OLD.IsDeleted IS DISTINCT FROM NEW.IsDeleted)
+EXECUTE PROCEDURE updateDeletedPosts();
+
 VACUUM;
 VACUUM ANALYSE;
\ No newline at end of file
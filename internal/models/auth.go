@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// easyjson -all ./internal/models/auth.go
+
+type Credentials struct {
+	Nickname string `json:"nickname"`
+	Password string `json:"password"`
+}
+
+type Token struct {
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// easyjson -all ./internal/models/cursor.go
+
+// Cursor is the decoded form of an opaque page cursor. It names the row the page
+// starts after (or, for a backward page, ends before) in the listing given by
+// Sort and Desc: Key is that row's sort key and Since its id, which breaks ties
+// between equal keys. The next page is read after these values as they were,
+// whatever happened to the row since.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d,omitempty"`
+	Back  bool   `json:"b,omitempty"`
+	Since string `json:"k"`
+	Key   string `json:"v"`
+}
+
+// easyjson:skip
+type Cursors struct {
+	Next string
+	Prev string
+}
+
+// Page is the body of a list page requested with a cursor parameter, which may
+// be empty for the first page: the items and the cursors around them. Requests
+// without one get the bare items and the cursors in headers only.
+// easyjson:skip
+type Page struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	PrevCursor string      `json:"prev_cursor,omitempty"`
+}
@@ -0,0 +1,113 @@
+// Code generated by easyjson for marshaling/unmarshaling. DO NOT EDIT.
+
+package models
+
+import (
+	json "encoding/json"
+	easyjson "github.com/mailru/easyjson"
+	jlexer "github.com/mailru/easyjson/jlexer"
+	jwriter "github.com/mailru/easyjson/jwriter"
+)
+
+// suppress unused package warning
+var (
+	_ *json.RawMessage
+	_ *jlexer.Lexer
+	_ *jwriter.Writer
+	_ easyjson.Marshaler
+)
+
+func easyjsonF2dd7f9eDecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(in *jlexer.Lexer, out *Cursor) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "s":
+			out.Sort = string(in.String())
+		case "d":
+			out.Desc = bool(in.Bool())
+		case "b":
+			out.Back = bool(in.Bool())
+		case "k":
+			out.Since = string(in.String())
+		case "v":
+			out.Key = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonF2dd7f9eEncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(out *jwriter.Writer, in Cursor) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"s\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Sort))
+	}
+	if in.Desc {
+		const prefix string = ",\"d\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Desc))
+	}
+	if in.Back {
+		const prefix string = ",\"b\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Back))
+	}
+	{
+		const prefix string = ",\"k\":"
+		out.RawString(prefix)
+		out.String(string(in.Since))
+	}
+	{
+		const prefix string = ",\"v\":"
+		out.RawString(prefix)
+		out.String(string(in.Key))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Cursor) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonF2dd7f9eEncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Cursor) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonF2dd7f9eEncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Cursor) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonF2dd7f9eDecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Cursor) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonF2dd7f9eDecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(l, v)
+}
@@ -2,6 +2,12 @@ package models
 
 // easyjson -all ./internal/models/errorResponse.go
 
+// ErrorResponse is the body of every error response. Code is one of the Code
+// constants; Resource and ID name the object concerned, when there is one.
 type ErrorResponse struct {
-	Message string `json:"message"`
+	Code     string      `json:"code,omitempty"`
+	Message  string      `json:"message"`
+	Resource string      `json:"resource,omitempty"`
+	ID       string      `json:"id,omitempty"`
+	Details  interface{} `json:"details,omitempty"`
 }
@@ -36,8 +36,22 @@ func easyjsonF772484dDecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(
 			continue
 		}
 		switch key {
+		case "code":
+			out.Code = string(in.String())
 		case "message":
 			out.Message = string(in.String())
+		case "resource":
+			out.Resource = string(in.String())
+		case "id":
+			out.ID = string(in.String())
+		case "details":
+			if m, ok := out.Details.(easyjson.Unmarshaler); ok {
+				m.UnmarshalEasyJSON(in)
+			} else if m, ok := out.Details.(json.Unmarshaler); ok {
+				_ = m.UnmarshalJSON(in.Raw())
+			} else {
+				out.Details = in.Interface()
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -52,11 +66,43 @@ func easyjsonF772484dEncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(
 	out.RawByte('{')
 	first := true
 	_ = first
+	if in.Code != "" {
+		const prefix string = ",\"code\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.String(string(in.Code))
+	}
 	{
 		const prefix string = ",\"message\":"
-		out.RawString(prefix[1:])
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
 		out.String(string(in.Message))
 	}
+	if in.Resource != "" {
+		const prefix string = ",\"resource\":"
+		out.RawString(prefix)
+		out.String(string(in.Resource))
+	}
+	if in.ID != "" {
+		const prefix string = ",\"id\":"
+		out.RawString(prefix)
+		out.String(string(in.ID))
+	}
+	if in.Details != nil {
+		const prefix string = ",\"details\":"
+		out.RawString(prefix)
+		if m, ok := in.Details.(easyjson.Marshaler); ok {
+			m.MarshalEasyJSON(out)
+		} else if m, ok := in.Details.(json.Marshaler); ok {
+			out.Raw(m.MarshalJSON())
+		} else {
+			out.Raw(json.Marshal(in.Details))
+		}
+	}
 	out.RawByte('}')
 }
 
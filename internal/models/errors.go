@@ -1,9 +1,89 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	Conflict      = errors.New("Conflict")
 	NotFound      = errors.New("NotFound")
 	InternalError = errors.New("InternalError")
+	Unauthorized  = errors.New("Unauthorized")
+	Forbidden     = errors.New("Forbidden")
+	ThreadClosed  = errors.New("ThreadClosed")
+	BadRequest    = errors.New("BadRequest")
+)
+
+// Error codes of ErrorResponse, one per sentinel error above.
+const (
+	CodeConflict      = "conflict"
+	CodeNotFound      = "not_found"
+	CodeInternalError = "internal_error"
+	CodeUnauthorized  = "unauthorized"
+	CodeForbidden     = "forbidden"
+	CodeThreadClosed  = "thread_closed"
+	CodeBadRequest    = "bad_request"
 )
+
+// Resources an Error can concern.
+const (
+	ResourceUser         = "user"
+	ResourceForum        = "forum"
+	ResourceThread       = "thread"
+	ResourcePost         = "post"
+	ResourceVote         = "vote"
+	ResourceRevision     = "revision"
+	ResourceNotification = "notification"
+	ResourceWebhook      = "webhook"
+)
+
+// Error wraps one of the sentinel errors above with the resource it concerns,
+// so callers keep matching the sentinel with errors.Is while responses can say
+// what was not found or conflicted. Details carries extra data for the client,
+// e.g. the existing object a create conflicted with.
+type Error struct {
+	Err      error
+	Resource string
+	ID       string
+	Message  string
+	Details  interface{}
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return e.Err.Error() + ": " + e.Message
+	}
+	if e.Resource == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s %s", e.Err, e.Resource, e.ID)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap ties err to the resource with the given id. It returns nil for nil and
+// keeps errors that already name their resource, which is always the more
+// specific one.
+func Wrap(err error, resource string, id interface{}) error {
+	if err == nil {
+		return nil
+	}
+	var typed *Error
+	if errors.As(err, &typed) {
+		return err
+	}
+	return &Error{Err: err, Resource: resource, ID: fmt.Sprint(id)}
+}
+
+// Describe is Wrap with a message for the client.
+func Describe(err error, resource string, id interface{}, message string) error {
+	return &Error{Err: err, Resource: resource, ID: fmt.Sprint(id), Message: message}
+}
+
+// WithDetails is Wrap with details for the client.
+func WithDetails(err error, resource string, id interface{}, details interface{}) error {
+	return &Error{Err: err, Resource: resource, ID: fmt.Sprint(id), Details: details}
+}
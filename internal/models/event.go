@@ -0,0 +1,32 @@
+package models
+
+import "github.com/mailru/easyjson"
+
+// easyjson -all ./internal/models/event.go
+
+type Event struct {
+	ID     int                 `json:"id"`
+	Type   string              `json:"type"`
+	Forum  string              `json:"forum"`
+	Thread int                 `json:"thread,omitempty"`
+	Author string              `json:"author,omitempty"`
+	Data   easyjson.RawMessage `json:"data"`
+}
+
+// Subscription is a command sent by WebSocket clients to change what they receive.
+type Subscription struct {
+	Action string   `json:"action"`
+	Forums []string `json:"forums,omitempty"`
+	Users  []string `json:"users,omitempty"`
+}
+
+const (
+	EventThreadCreated = "thread-created"
+	EventPostCreated   = "post-created"
+	EventPostEdited    = "post-edited"
+	// EventVoteChanged carries the thread or the post voted on, and its id.
+	EventVoteChanged = "vote-changed"
+
+	SubscribeAction   = "subscribe"
+	UnsubscribeAction = "unsubscribe"
+)
@@ -9,4 +9,13 @@ type Forum struct {
 	Slug    string `json:"slug"`
 	Posts   int    `json:"posts,omitempty"`
 	Threads int    `json:"threads,omitempty"`
+	Parent  string `json:"parent,omitempty"`
+
+	TotalPosts   int `json:"totalPosts,omitempty"`
+	TotalThreads int `json:"totalThreads,omitempty"`
+}
+
+type ForumNode struct {
+	Forum
+	Children []ForumNode `json:"children,omitempty"`
 }
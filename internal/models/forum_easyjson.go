@@ -17,7 +17,7 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjsonC8d74561DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(in *jlexer.Lexer, out *Forum) {
+func easyjsonC8d74561DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(in *jlexer.Lexer, out *ForumNode) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -36,6 +36,29 @@ func easyjsonC8d74561DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(
 			continue
 		}
 		switch key {
+		case "children":
+			if in.IsNull() {
+				in.Skip()
+				out.Children = nil
+			} else {
+				in.Delim('[')
+				if out.Children == nil {
+					if !in.IsDelim(']') {
+						out.Children = make([]ForumNode, 0, 0)
+					} else {
+						out.Children = []ForumNode{}
+					}
+				} else {
+					out.Children = (out.Children)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v1 ForumNode
+					(v1).UnmarshalEasyJSON(in)
+					out.Children = append(out.Children, v1)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
 		case "title":
 			out.Title = string(in.String())
 		case "user":
@@ -46,6 +69,12 @@ func easyjsonC8d74561DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(
 			out.Posts = int(in.Int())
 		case "threads":
 			out.Threads = int(in.Int())
+		case "parent":
+			out.Parent = string(in.String())
+		case "totalPosts":
+			out.TotalPosts = int(in.Int())
+		case "totalThreads":
+			out.TotalThreads = int(in.Int())
 		default:
 			in.SkipRecursive()
 		}
@@ -56,10 +85,25 @@ func easyjsonC8d74561DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(
 		in.Consumed()
 	}
 }
-func easyjsonC8d74561EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(out *jwriter.Writer, in Forum) {
+func easyjsonC8d74561EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(out *jwriter.Writer, in ForumNode) {
 	out.RawByte('{')
 	first := true
 	_ = first
+	if len(in.Children) != 0 {
+		const prefix string = ",\"children\":"
+		first = false
+		out.RawString(prefix[1:])
+		{
+			out.RawByte('[')
+			for v2, v3 := range in.Children {
+				if v2 > 0 {
+					out.RawByte(',')
+				}
+				(v3).MarshalEasyJSON(out)
+			}
+			out.RawByte(']')
+		}
+	}
 	{
 		const prefix string = ",\"title\":"
 		if first {
@@ -90,29 +134,164 @@ func easyjsonC8d74561EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(
 		out.RawString(prefix)
 		out.Int(int(in.Threads))
 	}
+	if in.Parent != "" {
+		const prefix string = ",\"parent\":"
+		out.RawString(prefix)
+		out.String(string(in.Parent))
+	}
+	if in.TotalPosts != 0 {
+		const prefix string = ",\"totalPosts\":"
+		out.RawString(prefix)
+		out.Int(int(in.TotalPosts))
+	}
+	if in.TotalThreads != 0 {
+		const prefix string = ",\"totalThreads\":"
+		out.RawString(prefix)
+		out.Int(int(in.TotalThreads))
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
-func (v Forum) MarshalJSON() ([]byte, error) {
+func (v ForumNode) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
 	easyjsonC8d74561EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
-func (v Forum) MarshalEasyJSON(w *jwriter.Writer) {
+func (v ForumNode) MarshalEasyJSON(w *jwriter.Writer) {
 	easyjsonC8d74561EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
-func (v *Forum) UnmarshalJSON(data []byte) error {
+func (v *ForumNode) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
 	easyjsonC8d74561DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
-func (v *Forum) UnmarshalEasyJSON(l *jlexer.Lexer) {
+func (v *ForumNode) UnmarshalEasyJSON(l *jlexer.Lexer) {
 	easyjsonC8d74561DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(l, v)
 }
+func easyjsonC8d74561DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels1(in *jlexer.Lexer, out *Forum) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "title":
+			out.Title = string(in.String())
+		case "user":
+			out.User = string(in.String())
+		case "slug":
+			out.Slug = string(in.String())
+		case "posts":
+			out.Posts = int(in.Int())
+		case "threads":
+			out.Threads = int(in.Int())
+		case "parent":
+			out.Parent = string(in.String())
+		case "totalPosts":
+			out.TotalPosts = int(in.Int())
+		case "totalThreads":
+			out.TotalThreads = int(in.Int())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC8d74561EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels1(out *jwriter.Writer, in Forum) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"title\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Title))
+	}
+	{
+		const prefix string = ",\"user\":"
+		out.RawString(prefix)
+		out.String(string(in.User))
+	}
+	{
+		const prefix string = ",\"slug\":"
+		out.RawString(prefix)
+		out.String(string(in.Slug))
+	}
+	if in.Posts != 0 {
+		const prefix string = ",\"posts\":"
+		out.RawString(prefix)
+		out.Int(int(in.Posts))
+	}
+	if in.Threads != 0 {
+		const prefix string = ",\"threads\":"
+		out.RawString(prefix)
+		out.Int(int(in.Threads))
+	}
+	if in.Parent != "" {
+		const prefix string = ",\"parent\":"
+		out.RawString(prefix)
+		out.String(string(in.Parent))
+	}
+	if in.TotalPosts != 0 {
+		const prefix string = ",\"totalPosts\":"
+		out.RawString(prefix)
+		out.Int(int(in.TotalPosts))
+	}
+	if in.TotalThreads != 0 {
+		const prefix string = ",\"totalThreads\":"
+		out.RawString(prefix)
+		out.Int(int(in.TotalThreads))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v Forum) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC8d74561EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v Forum) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC8d74561EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *Forum) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC8d74561DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *Forum) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC8d74561DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels1(l, v)
+}
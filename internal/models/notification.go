@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// easyjson -all ./internal/models/notification.go
+
+type Notification struct {
+	ID       int       `json:"id"`
+	Nickname string    `json:"nickname"`
+	Kind     string    `json:"kind"`
+	Post     int       `json:"post"`
+	Thread   int       `json:"thread"`
+	Forum    string    `json:"forum"`
+	Author   string    `json:"author"`
+	Read     bool      `json:"read"`
+	Created  time.Time `json:"created"`
+}
+
+// easyjson:skip
+type NotificationParams struct {
+	Unread bool
+	Limit  int
+	Since  int
+}
+
+const (
+	NotificationReply   = "reply"
+	NotificationMention = "mention"
+	// NotificationThread tells a thread's author about a new root post.
+	NotificationThread = "thread"
+)
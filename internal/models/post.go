@@ -7,24 +7,39 @@ import (
 
 // easyjson -all ./internal/models/post.go
 
+// MessageHTML is only filled for ?format=html; Reactions counts users per emoji.
 type Post struct {
-	ID       int              `json:"id,omitempty"`
-	Parent   int              `json:"parent,omitempty"`
-	Author   string           `json:"author"`
-	Message  string           `json:"message"`
-	IsEdited bool             `json:"isEdited,omitempty"`
-	Forum    string           `json:"forum,omitempty"`
-	Thread   int              `json:"thread,omitempty"`
-	Created  time.Time        `json:"created,omitempty"`
-	Path     pgtype.Int4Array `json:"path,omitempty"`
+	ID          int              `json:"id,omitempty"`
+	Parent      int              `json:"parent,omitempty"`
+	Author      string           `json:"author"`
+	Message     string           `json:"message"`
+	MessageHTML string           `json:"messageHtml,omitempty"`
+	IsEdited    bool             `json:"isEdited,omitempty"`
+	Forum       string           `json:"forum,omitempty"`
+	Thread      int              `json:"thread,omitempty"`
+	Created     time.Time        `json:"created,omitempty"`
+	Path        pgtype.Int4Array `json:"path,omitempty"`
+	IsDeleted   bool             `json:"isDeleted,omitempty"`
+	Score       int              `json:"score,omitempty"`
+	Reactions   map[string]int   `json:"reactions,omitempty"`
+	// Key is the position of the post in a tree or score listing, kept for
+	// the cursors of the page.
+	Key string `json:"-"`
 }
 
+// DeletedPostMessage replaces the message of a soft-deleted post.
+const DeletedPostMessage = "[deleted]"
+
 // easyjson:skip
 type SortParams struct {
-	Limit string
-	Since string
-	Desc  string
-	Sort  string
+	Limit  string
+	Since  string
+	Desc   string
+	Sort   string
+	Cursor string
+	// Key is the sort key of the row Since names; cursor pages set it, and
+	// the page then continues after that (key, since) pair.
+	Key string
 }
 
 type PostFull struct {
@@ -37,4 +52,5 @@ type PostFull struct {
 type PostUpdate struct {
 	ID      int    `json:"id,omitempty"`
 	Message string `json:"message,omitempty"`
+	Editor  string `json:"-"`
 }
@@ -251,6 +251,8 @@ func easyjson5a72dc82DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels2
 			out.Author = string(in.String())
 		case "message":
 			out.Message = string(in.String())
+		case "messageHtml":
+			out.MessageHTML = string(in.String())
 		case "isEdited":
 			out.IsEdited = bool(in.Bool())
 		case "forum":
@@ -263,6 +265,30 @@ func easyjson5a72dc82DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels2
 			}
 		case "path":
 			easyjson5a72dc82DecodeGithubComJackcPgtype(in, &out.Path)
+		case "isDeleted":
+			out.IsDeleted = bool(in.Bool())
+		case "score":
+			out.Score = int(in.Int())
+		case "reactions":
+			if in.IsNull() {
+				in.Skip()
+			} else {
+				in.Delim('{')
+				if !in.IsDelim('}') {
+					out.Reactions = make(map[string]int)
+				} else {
+					out.Reactions = nil
+				}
+				for !in.IsDelim('}') {
+					key := string(in.String())
+					in.WantColon()
+					var v1 int
+					v1 = int(in.Int())
+					(out.Reactions)[key] = v1
+					in.WantComma()
+				}
+				in.Delim('}')
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -308,6 +334,11 @@ func easyjson5a72dc82EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels2
 		out.RawString(prefix)
 		out.String(string(in.Message))
 	}
+	if in.MessageHTML != "" {
+		const prefix string = ",\"messageHtml\":"
+		out.RawString(prefix)
+		out.String(string(in.MessageHTML))
+	}
 	if in.IsEdited {
 		const prefix string = ",\"isEdited\":"
 		out.RawString(prefix)
@@ -333,6 +364,35 @@ func easyjson5a72dc82EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels2
 		out.RawString(prefix)
 		easyjson5a72dc82EncodeGithubComJackcPgtype(out, in.Path)
 	}
+	if in.IsDeleted {
+		const prefix string = ",\"isDeleted\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.IsDeleted))
+	}
+	if in.Score != 0 {
+		const prefix string = ",\"score\":"
+		out.RawString(prefix)
+		out.Int(int(in.Score))
+	}
+	if len(in.Reactions) != 0 {
+		const prefix string = ",\"reactions\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('{')
+			v2First := true
+			for v2Name, v2Value := range in.Reactions {
+				if v2First {
+					v2First = false
+				} else {
+					out.RawByte(',')
+				}
+				out.String(string(v2Name))
+				out.RawByte(':')
+				out.Int(int(v2Value))
+			}
+			out.RawByte('}')
+		}
+	}
 	out.RawByte('}')
 }
 
@@ -394,11 +454,11 @@ func easyjson5a72dc82DecodeGithubComJackcPgtype(in *jlexer.Lexer, out *pgtype.In
 					out.Elements = (out.Elements)[:0]
 				}
 				for !in.IsDelim(']') {
-					var v1 pgtype.Int4
+					var v3 pgtype.Int4
 					if data := in.Raw(); in.Ok() {
-						in.AddError((v1).UnmarshalJSON(data))
+						in.AddError((v3).UnmarshalJSON(data))
 					}
-					out.Elements = append(out.Elements, v1)
+					out.Elements = append(out.Elements, v3)
 					in.WantComma()
 				}
 				in.Delim(']')
@@ -419,9 +479,9 @@ func easyjson5a72dc82DecodeGithubComJackcPgtype(in *jlexer.Lexer, out *pgtype.In
 					out.Dimensions = (out.Dimensions)[:0]
 				}
 				for !in.IsDelim(']') {
-					var v2 pgtype.ArrayDimension
-					easyjson5a72dc82DecodeGithubComJackcPgtype1(in, &v2)
-					out.Dimensions = append(out.Dimensions, v2)
+					var v4 pgtype.ArrayDimension
+					easyjson5a72dc82DecodeGithubComJackcPgtype1(in, &v4)
+					out.Dimensions = append(out.Dimensions, v4)
 					in.WantComma()
 				}
 				in.Delim(']')
@@ -449,11 +509,11 @@ func easyjson5a72dc82EncodeGithubComJackcPgtype(out *jwriter.Writer, in pgtype.I
 			out.RawString("null")
 		} else {
 			out.RawByte('[')
-			for v3, v4 := range in.Elements {
-				if v3 > 0 {
+			for v5, v6 := range in.Elements {
+				if v5 > 0 {
 					out.RawByte(',')
 				}
-				out.Raw((v4).MarshalJSON())
+				out.Raw((v6).MarshalJSON())
 			}
 			out.RawByte(']')
 		}
@@ -465,11 +525,11 @@ func easyjson5a72dc82EncodeGithubComJackcPgtype(out *jwriter.Writer, in pgtype.I
 			out.RawString("null")
 		} else {
 			out.RawByte('[')
-			for v5, v6 := range in.Dimensions {
-				if v5 > 0 {
+			for v7, v8 := range in.Dimensions {
+				if v7 > 0 {
 					out.RawByte(',')
 				}
-				easyjson5a72dc82EncodeGithubComJackcPgtype1(out, v6)
+				easyjson5a72dc82EncodeGithubComJackcPgtype1(out, v8)
 			}
 			out.RawByte(']')
 		}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// easyjson -all ./internal/models/reaction.go
+
+type Reaction struct {
+	Post     int       `json:"post"`
+	Nickname string    `json:"nickname"`
+	Emoji    string    `json:"emoji"`
+	Created  time.Time `json:"created"`
+}
+
+// DefaultReactions is the allowed set used when none is configured.
+var DefaultReactions = []string{"👍", "👎", "❤️", "😄", "🎉", "😕"}
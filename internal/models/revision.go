@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// easyjson -all ./internal/models/revision.go
+
+type PostRevision struct {
+	Version int       `json:"version"`
+	Post    int       `json:"post"`
+	Message string    `json:"message"`
+	Editor  string    `json:"editor"`
+	Created time.Time `json:"created"`
+}
+
+type DiffChange struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type PostDiff struct {
+	Post    int          `json:"post"`
+	From    int          `json:"from"`
+	To      int          `json:"to"`
+	Mode    string       `json:"mode"`
+	Changes []DiffChange `json:"changes"`
+}
+
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+
+	DiffModeLine = "line"
+	DiffModeWord = "word"
+)
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// easyjson -all ./internal/models/search.go
+
+type SearchHit struct {
+	Type    string    `json:"type"`
+	ID      int       `json:"id"`
+	Thread  int       `json:"thread"`
+	Forum   string    `json:"forum"`
+	Author  string    `json:"author"`
+	Title   string    `json:"title"`
+	Snippet string    `json:"snippet"`
+	Rank    float32   `json:"rank"`
+	Created time.Time `json:"created"`
+}
+
+// SearchResult pages like the other lists: NextCursor goes in the cursor
+// parameter of the next request.
+type SearchResult struct {
+	Hits       []SearchHit `json:"hits"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// easyjson:skip
+type SearchParams struct {
+	Query  string
+	Forum  string
+	Author string
+	Limit  int
+	After  *SearchHit
+}
+
+const (
+	SearchHitPost   = "post"
+	SearchHitThread = "thread"
+)
@@ -4,13 +4,19 @@ import "time"
 
 // easyjson -all ./internal/models/thread.go
 
+// MessageHTML is only filled when the client asks for ?format=html.
 type Thread struct {
-	ID      int       `json:"id,omitempty"`
-	Title   string    `json:"title"`
-	Author  string    `json:"author"`
-	Forum   string    `json:"forum"`
-	Message string    `json:"message"`
-	Votes   int       `json:"votes,omitempty"`
-	Slug    string    `json:"slug,omitempty"`
-	Created time.Time `json:"created,omitempty"`
+	ID          int       `json:"id,omitempty"`
+	Title       string    `json:"title"`
+	Author      string    `json:"author"`
+	Forum       string    `json:"forum"`
+	Message     string    `json:"message"`
+	MessageHTML string    `json:"messageHtml,omitempty"`
+	Votes       int       `json:"votes,omitempty"`
+	Slug        string    `json:"slug,omitempty"`
+	Created     time.Time `json:"created,omitempty"`
+	Closed      bool      `json:"closed,omitempty"`
+	Pinned      bool      `json:"pinned,omitempty"`
+	Replies     int       `json:"replies,omitempty"`
+	LastPostAt  time.Time `json:"lastPostAt"`
 }
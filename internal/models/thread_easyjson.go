@@ -46,6 +46,8 @@ func easyjson2d00218DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(i
 			out.Forum = string(in.String())
 		case "message":
 			out.Message = string(in.String())
+		case "messageHtml":
+			out.MessageHTML = string(in.String())
 		case "votes":
 			out.Votes = int(in.Int())
 		case "slug":
@@ -54,6 +56,16 @@ func easyjson2d00218DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(i
 			if data := in.Raw(); in.Ok() {
 				in.AddError((out.Created).UnmarshalJSON(data))
 			}
+		case "closed":
+			out.Closed = bool(in.Bool())
+		case "pinned":
+			out.Pinned = bool(in.Bool())
+		case "replies":
+			out.Replies = int(in.Int())
+		case "lastPostAt":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.LastPostAt).UnmarshalJSON(data))
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -99,6 +111,11 @@ func easyjson2d00218EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(o
 		out.RawString(prefix)
 		out.String(string(in.Message))
 	}
+	if in.MessageHTML != "" {
+		const prefix string = ",\"messageHtml\":"
+		out.RawString(prefix)
+		out.String(string(in.MessageHTML))
+	}
 	if in.Votes != 0 {
 		const prefix string = ",\"votes\":"
 		out.RawString(prefix)
@@ -114,6 +131,26 @@ func easyjson2d00218EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(o
 		out.RawString(prefix)
 		out.Raw((in.Created).MarshalJSON())
 	}
+	if in.Closed {
+		const prefix string = ",\"closed\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Closed))
+	}
+	if in.Pinned {
+		const prefix string = ",\"pinned\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Pinned))
+	}
+	if in.Replies != 0 {
+		const prefix string = ",\"replies\":"
+		out.RawString(prefix)
+		out.Int(int(in.Replies))
+	}
+	{
+		const prefix string = ",\"lastPostAt\":"
+		out.RawString(prefix)
+		out.Raw((in.LastPostAt).MarshalJSON())
+	}
 	out.RawByte('}')
 }
 
@@ -8,4 +8,11 @@ type User struct {
 	FullName string `json:"fullname"`
 	About    string `json:"about,omitempty"`
 	Email    string `json:"email"`
+	Password string `json:"password,omitempty"`
+	Role     string `json:"role,omitempty"`
 }
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
@@ -44,6 +44,10 @@ func easyjson9e1087fdDecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(
 			out.About = string(in.String())
 		case "email":
 			out.Email = string(in.String())
+		case "password":
+			out.Password = string(in.String())
+		case "role":
+			out.Role = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -88,6 +92,16 @@ func easyjson9e1087fdEncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(
 		out.RawString(prefix)
 		out.String(string(in.Email))
 	}
+	if in.Password != "" {
+		const prefix string = ",\"password\":"
+		out.RawString(prefix)
+		out.String(string(in.Password))
+	}
+	if in.Role != "" {
+		const prefix string = ",\"role\":"
+		out.RawString(prefix)
+		out.String(string(in.Role))
+	}
 	out.RawByte('}')
 }
 
@@ -0,0 +1,287 @@
+package models
+
+import (
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// easyjson -all ./internal/models/validation.go
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a request; the fields are the
+// details of its 400 response, and it matches BadRequest under errors.Is.
+type ValidationError struct {
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Errors))
+	for _, field := range e.Errors {
+		fields = append(fields, field.Field+": "+field.Message)
+	}
+	return "invalid request: " + strings.Join(fields, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == BadRequest
+}
+
+// MaxLimit bounds the limit parameter of the list endpoints.
+const MaxLimit = 10000
+
+var (
+	nicknamePattern = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+	slugPattern     = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	numberPattern   = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// Validator collects the field errors of one request.
+// easyjson:skip
+type Validator struct {
+	errors []FieldError
+}
+
+// Check records message for field unless ok.
+func (v *Validator) Check(ok bool, field string, message string) {
+	if !ok {
+		v.errors = append(v.errors, FieldError{Field: field, Message: message})
+	}
+}
+
+func (v *Validator) Required(field string, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, "is required")
+}
+
+// NotBlank checks a field of a partial update, which may be empty to keep the
+// current value but not whitespace only.
+func (v *Validator) NotBlank(field string, value string) {
+	v.Check(value == "" || strings.TrimSpace(value) != "", field, "must not be blank")
+}
+
+func (v *Validator) Nickname(field string, value string) {
+	v.Check(value == "" || nicknamePattern.MatchString(value), field, "may only contain letters, digits, '_' and '.'")
+}
+
+func (v *Validator) Email(field string, value string) {
+	if value == "" {
+		return
+	}
+	address, err := mail.ParseAddress(value)
+	v.Check(err == nil && address.Address == value, field, "is not a valid email address")
+}
+
+func (v *Validator) Slug(field string, value string) {
+	v.Check(value == "" || slugPattern.MatchString(value), field, "may only contain letters, digits, '-' and '_'")
+}
+
+func (v *Validator) URL(field string, value string) {
+	if value == "" {
+		return
+	}
+	target, err := url.Parse(value)
+	v.Check(err == nil && (target.Scheme == "http" || target.Scheme == "https") && target.Host != "",
+		field, "must be an absolute http(s) url")
+}
+
+// Int checks that a non-empty value is an integer within [min, max].
+func (v *Validator) Int(field string, value string, min int, max int) {
+	if value == "" {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	v.Check(err == nil && n >= min && n <= max, field,
+		"must be an integer from "+strconv.Itoa(min)+" to "+strconv.Itoa(max))
+}
+
+// OneOf checks that a non-empty value is one of options.
+func (v *Validator) OneOf(field string, value string, options ...string) {
+	if value == "" {
+		return
+	}
+	for _, option := range options {
+		if value == option {
+			return
+		}
+	}
+	v.Check(false, field, "must be one of "+strings.Join(options, ", "))
+}
+
+// Since checks a non-empty since against the sort of the listing it pages: a
+// nickname for users, a timestamp for threads by creation or activity, and an
+// integer, a post id or a count, for the other sorts.
+func (v *Validator) Since(sort string, value string) {
+	if value == "" {
+		return
+	}
+	switch sort {
+	case "nickname":
+		v.Check(nicknamePattern.MatchString(value), "since", "must be a nickname")
+	case "created", "activity":
+		_, err := time.Parse(time.RFC3339, value)
+		v.Check(err == nil, "since", "must be an RFC 3339 timestamp")
+	default:
+		_, err := strconv.Atoi(value)
+		v.Check(err == nil, "since", "must be an integer")
+	}
+}
+
+// Merge adds the field errors of a nested validation, e.g. of the i-th element
+// of a list, with their fields under prefix.
+func (v *Validator) Merge(prefix string, err error) {
+	if invalid, ok := err.(*ValidationError); ok {
+		for _, field := range invalid.Errors {
+			v.Check(false, prefix+field.Field, field.Message)
+		}
+	}
+}
+
+// Err returns the collected errors as a *ValidationError, or nil.
+func (v *Validator) Err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return &ValidationError{Message: "Invalid request", Errors: v.errors}
+}
+
+// InvalidField is the error of a request failing on a single field.
+func InvalidField(field string, message string) error {
+	v := Validator{}
+	v.Check(false, field, message)
+	return v.Err()
+}
+
+func (u User) Validate() error {
+	v := Validator{}
+	v.Required("nickname", u.NickName)
+	v.Nickname("nickname", u.NickName)
+	v.Required("fullname", u.FullName)
+	v.Required("email", u.Email)
+	v.Email("email", u.Email)
+	return v.Err()
+}
+
+// ValidateUpdate checks a partial update, where empty fields stay unchanged.
+func (u User) ValidateUpdate() error {
+	v := Validator{}
+	v.Email("email", u.Email)
+	return v.Err()
+}
+
+func (c Credentials) Validate() error {
+	v := Validator{}
+	v.Required("nickname", c.Nickname)
+	v.Required("password", c.Password)
+	return v.Err()
+}
+
+func (f Forum) Validate() error {
+	v := Validator{}
+	v.Required("title", f.Title)
+	v.Required("slug", f.Slug)
+	v.Slug("slug", f.Slug)
+	v.Slug("parent", f.Parent)
+	v.Check(f.Parent == "" || !strings.EqualFold(f.Parent, f.Slug), "parent", "must differ from slug")
+	return v.Err()
+}
+
+// ValidateUpdate checks a partial update, where empty fields stay unchanged.
+// Only the title and the owner can be updated; a forum keeps its parent.
+func (f Forum) ValidateUpdate() error {
+	v := Validator{}
+	v.Nickname("user", f.User)
+	return v.Err()
+}
+
+func (t Thread) Validate() error {
+	v := Validator{}
+	v.Required("title", t.Title)
+	v.Required("message", t.Message)
+	v.threadSlug(t.Slug)
+	return v.Err()
+}
+
+// ValidateUpdate checks a partial update, where empty fields stay unchanged.
+func (t Thread) ValidateUpdate() error {
+	v := Validator{}
+	v.NotBlank("title", t.Title)
+	v.NotBlank("message", t.Message)
+	v.threadSlug(t.Slug)
+	return v.Err()
+}
+
+// ValidateSplit checks the new thread of a split, which takes its message from
+// the first post moved.
+func (t Thread) ValidateSplit() error {
+	v := Validator{}
+	v.Required("title", t.Title)
+	v.threadSlug(t.Slug)
+	return v.Err()
+}
+
+// threadSlug also rejects slugs made of digits only, which would be taken for
+// an id wherever a thread is addressed by slug or id.
+func (v *Validator) threadSlug(slug string) {
+	v.Slug("slug", slug)
+	v.Check(!numberPattern.MatchString(slug), "slug", "must not be a number")
+}
+
+func (p Post) Validate() error {
+	v := Validator{}
+	v.Required("message", p.Message)
+	v.Check(p.Parent >= 0, "parent", "must be a post id")
+	return v.Err()
+}
+
+// Validate checks an edit, where an empty message keeps the current one.
+func (p PostUpdate) Validate() error {
+	v := Validator{}
+	v.NotBlank("message", p.Message)
+	return v.Err()
+}
+
+// Validate checks the voice of a cast vote.
+func (vote Vote) Validate() error {
+	v := Validator{}
+	v.Check(vote.Voice == 1 || vote.Voice == -1, "voice", "must be 1 or -1")
+	return v.Err()
+}
+
+func (r Reaction) Validate() error {
+	v := Validator{}
+	v.Required("emoji", r.Emoji)
+	return v.Err()
+}
+
+func (w Webhook) Validate() error {
+	v := Validator{}
+	v.Required("url", w.URL)
+	v.URL("url", w.URL)
+	return v.Err()
+}
+
+// Validate checks the paging parameters of a list endpoint; sorts, when given,
+// are the values it accepts for sort, the first being the default. since is
+// checked against the sort in effect and is a post id when there is none.
+func (p SortParams) Validate(sorts ...string) error {
+	v := Validator{}
+	v.Int("limit", p.Limit, 1, MaxLimit)
+	v.OneOf("desc", p.Desc, "true", "false")
+	sort := p.Sort
+	if len(sorts) > 0 {
+		v.OneOf("sort", p.Sort, sorts...)
+		if sort == "" {
+			sort = sorts[0]
+		}
+	}
+	v.Since(sort, p.Since)
+	return v.Err()
+}
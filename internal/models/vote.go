@@ -1,9 +1,15 @@
 package models
 
+import "time"
+
 // easyjson -all ./internal/models/vote.go
 
+// Voted and Retracted are only set when the votes of a thread are listed.
 type Vote struct {
-	Nickname string `json:"nickname"`
-	Voice    int    `json:"voice"`
-	Thread   int    `json:"-"`
+	Nickname  string     `json:"nickname"`
+	Voice     int        `json:"voice"`
+	Thread    int        `json:"-"`
+	Post      int        `json:"-"`
+	Voted     *time.Time `json:"voted,omitempty"`
+	Retracted *time.Time `json:"retracted,omitempty"`
 }
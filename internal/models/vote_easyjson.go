@@ -7,6 +7,7 @@ import (
 	easyjson "github.com/mailru/easyjson"
 	jlexer "github.com/mailru/easyjson/jlexer"
 	jwriter "github.com/mailru/easyjson/jwriter"
+	time "time"
 )
 
 // suppress unused package warning
@@ -40,6 +41,30 @@ func easyjsonE3ecfa40DecodeGithubComDESOLATE17DatabaseTermProjectInternalModels(
 			out.Nickname = string(in.String())
 		case "voice":
 			out.Voice = int(in.Int())
+		case "voted":
+			if in.IsNull() {
+				in.Skip()
+				out.Voted = nil
+			} else {
+				if out.Voted == nil {
+					out.Voted = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.Voted).UnmarshalJSON(data))
+				}
+			}
+		case "retracted":
+			if in.IsNull() {
+				in.Skip()
+				out.Retracted = nil
+			} else {
+				if out.Retracted == nil {
+					out.Retracted = new(time.Time)
+				}
+				if data := in.Raw(); in.Ok() {
+					in.AddError((*out.Retracted).UnmarshalJSON(data))
+				}
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -64,6 +89,16 @@ func easyjsonE3ecfa40EncodeGithubComDESOLATE17DatabaseTermProjectInternalModels(
 		out.RawString(prefix)
 		out.Int(int(in.Voice))
 	}
+	if in.Voted != nil {
+		const prefix string = ",\"voted\":"
+		out.RawString(prefix)
+		out.Raw((*in.Voted).MarshalJSON())
+	}
+	if in.Retracted != nil {
+		const prefix string = ",\"retracted\":"
+		out.RawString(prefix)
+		out.Raw((*in.Retracted).MarshalJSON())
+	}
 	out.RawByte('}')
 }
 
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"github.com/mailru/easyjson"
+	"time"
+)
+
+// easyjson -all ./internal/models/webhook.go
+
+type Webhook struct {
+	ID      int       `json:"id"`
+	Forum   string    `json:"forum"`
+	URL     string    `json:"url"`
+	Events  []string  `json:"events"`
+	Secret  string    `json:"secret,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+type WebhookDelivery struct {
+	ID           int                 `json:"id"`
+	Webhook      int                 `json:"webhook"`
+	Event        string              `json:"event"`
+	Payload      easyjson.RawMessage `json:"payload"`
+	Status       string              `json:"status"`
+	Attempts     int                 `json:"attempts"`
+	ResponseCode int                 `json:"responseCode,omitempty"`
+	LastError    string              `json:"lastError,omitempty"`
+	NextAttempt  time.Time           `json:"nextAttempt"`
+	Created      time.Time           `json:"created"`
+	Delivered    *time.Time          `json:"delivered,omitempty"`
+	URL          string              `json:"-"`
+	Secret       string              `json:"-"`
+}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
@@ -0,0 +1,16 @@
+package auth
+
+import "context"
+
+type ctxKey struct{}
+
+// WithCaller stores the nickname of the authenticated user in ctx.
+func WithCaller(ctx context.Context, nickname string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, nickname)
+}
+
+// Caller returns the nickname resolved by the auth middleware, if any.
+func Caller(ctx context.Context) (string, bool) {
+	nickname, ok := ctx.Value(ctxKey{}).(string)
+	return nickname, ok && nickname != ""
+}
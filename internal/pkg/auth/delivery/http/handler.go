@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/auth"
+	"github.com/DESOLATE17/Database-term-project/internal/utils"
+	"net/http"
+	"strings"
+)
+
+type Handler struct {
+	uc auth.UseCase
+}
+
+func NewAuthHandler(AuthUseCase auth.UseCase) *Handler {
+	return &Handler{uc: AuthUseCase}
+}
+
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	credentials := models.Credentials{}
+	if utils.Invalid(w, utils.Decode(r, &credentials)) {
+		return
+	}
+	if utils.Invalid(w, credentials.Validate()) {
+		return
+	}
+
+	token, err := h.uc.Login(r.Context(), credentials)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, token)
+}
+
+// Middleware resolves the caller from the bearer token. Requests without a token
+// pass through anonymously; the use cases decide whether that is allowed.
+func (h *Handler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !strings.HasPrefix(header, "Bearer ") {
+			utils.Error(w, models.Describe(models.Unauthorized, "", "", "Malformed authorization header"))
+			return
+		}
+		nickname, err := h.uc.ParseToken(r.Context(), strings.TrimPrefix(header, "Bearer "))
+		if err != nil {
+			utils.Error(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.WithCaller(r.Context(), nickname)))
+	})
+}
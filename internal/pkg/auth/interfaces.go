@@ -0,0 +1,15 @@
+package auth
+
+import (
+	"context"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+)
+
+type UseCase interface {
+	Login(ctx context.Context, credentials models.Credentials) (models.Token, error)
+	ParseToken(ctx context.Context, token string) (string, error)
+}
+
+type Repository interface {
+	GetPasswordHash(ctx context.Context, nickname string) (string, string, error)
+}
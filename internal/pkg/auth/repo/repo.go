@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"context"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/auth"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type repoPostgres struct {
+	Conn *pgxpool.Pool
+}
+
+func NewRepoPostgres(Conn *pgxpool.Pool) auth.Repository {
+	return &repoPostgres{Conn: Conn}
+}
+
+// GetPasswordHash returns the canonical nickname and the stored password hash.
+func (r *repoPostgres) GetPasswordHash(ctx context.Context, nickname string) (string, string, error) {
+	const (
+		SelectPassword = `SELECT nickname, password
+						  FROM users WHERE nickname=$1
+						  LIMIT 1;`
+	)
+	var hash string
+	row := r.Conn.QueryRow(ctx, SelectPassword, nickname)
+	err := row.Scan(&nickname, &hash)
+	if err != nil {
+		return "", "", models.NotFound
+	}
+	return nickname, hash, nil
+}
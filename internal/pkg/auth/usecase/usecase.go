@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/auth"
+	"golang.org/x/crypto/bcrypt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const tokenTTL = 24 * time.Hour
+
+type UseCase struct {
+	repo   auth.Repository
+	secret []byte
+}
+
+var (
+	errWrongCredentials = models.Describe(models.Unauthorized, "", "", "Wrong nickname or password")
+	errInvalidToken     = models.Describe(models.Unauthorized, "", "", "Invalid or expired token")
+)
+
+func NewAuthUsecase(repo auth.Repository, secret []byte) auth.UseCase {
+	return &UseCase{repo: repo, secret: secret}
+}
+
+func (u *UseCase) Login(ctx context.Context, credentials models.Credentials) (models.Token, error) {
+	nickname, hash, err := u.repo.GetPasswordHash(ctx, credentials.Nickname)
+	if err != nil || hash == "" {
+		return models.Token{}, errWrongCredentials
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(credentials.Password)) != nil {
+		return models.Token{}, errWrongCredentials
+	}
+
+	expires := time.Now().Add(tokenTTL).UTC().Truncate(time.Second)
+	payload := nickname + "|" + strconv.FormatInt(expires.Unix(), 10)
+	token := base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + u.sign(payload)
+	return models.Token{Token: token, Expires: expires}, nil
+}
+
+// ParseToken checks the token signature and expiry and returns the nickname it was issued to.
+func (u *UseCase) ParseToken(ctx context.Context, token string) (string, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return "", errInvalidToken
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errInvalidToken
+	}
+	payload := string(raw)
+	if !hmac.Equal([]byte(signature), []byte(u.sign(payload))) {
+		return "", errInvalidToken
+	}
+
+	sep := strings.LastIndex(payload, "|")
+	if sep < 0 {
+		return "", errInvalidToken
+	}
+	expires, err := strconv.ParseInt(payload[sep+1:], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", errInvalidToken
+	}
+	return payload[:sep], nil
+}
+
+func (u *UseCase) sign(payload string) string {
+	mac := hmac.New(sha256.New, u.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,16 @@
+package events
+
+import "github.com/DESOLATE17/Database-term-project/internal/models"
+
+type Publisher interface {
+	Publish(event models.Event)
+}
+
+// Fanout hands every event to each of its publishers in order.
+type Fanout []Publisher
+
+func (f Fanout) Publish(event models.Event) {
+	for _, p := range f {
+		p.Publish(event)
+	}
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"sync"
+)
+
+// Hub fans events out to the subscribers of this process.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	C      chan models.Event
+	filter func(models.Event) bool
+	hub    *Hub
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{})}
+}
+
+// Subscribe registers a subscriber receiving the events accepted by filter.
+// A nil filter accepts every event.
+func (h *Hub) Subscribe(buffer int, filter func(models.Event) bool) *Subscription {
+	s := &Subscription{C: make(chan models.Event, buffer), filter: filter, hub: h}
+	h.mu.Lock()
+	h.subs[s] = struct{}{}
+	h.mu.Unlock()
+	return s
+}
+
+// Publish delivers the event without ever blocking the publisher. A subscriber
+// whose buffer is full is dropped and its channel closed, so a slow client
+// notices it has missed events and can reconnect and resume.
+func (h *Hub) Publish(event models.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for s := range h.subs {
+		if s.filter != nil && !s.filter(event) {
+			continue
+		}
+		select {
+		case s.C <- event:
+		default:
+			delete(h.subs, s)
+			close(s.C)
+		}
+	}
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if _, ok := s.hub.subs[s]; ok {
+		delete(s.hub.subs, s)
+		close(s.C)
+	}
+}
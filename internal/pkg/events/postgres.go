@@ -0,0 +1,70 @@
+package events
+
+import (
+	"context"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mailru/easyjson"
+	"log"
+	"time"
+)
+
+const notifyChannel = "forum_events"
+
+// PgNotifier shares events between several instances through PostgreSQL
+// LISTEN/NOTIFY. Events are published with NOTIFY and delivered to the local
+// hub only once they come back from the database.
+type PgNotifier struct {
+	pool *pgxpool.Pool
+	hub  *Hub
+}
+
+func NewPgNotifier(pool *pgxpool.Pool, hub *Hub) *PgNotifier {
+	return &PgNotifier{pool: pool, hub: hub}
+}
+
+func (n *PgNotifier) Publish(event models.Event) {
+	payload, err := easyjson.Marshal(event)
+	if err != nil {
+		return
+	}
+	_, err = n.pool.Exec(context.Background(), `SELECT pg_notify($1, $2);`, notifyChannel, string(payload))
+	if err != nil {
+		// NOTIFY payloads are limited to 8000 bytes, keep at least local subscribers informed.
+		log.Print("events: notify failed: ", err)
+		n.hub.Publish(event)
+	}
+}
+
+// Listen forwards notifications to the local hub until ctx is done.
+func (n *PgNotifier) Listen(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := n.listen(ctx); err != nil && ctx.Err() == nil {
+			log.Print("events: listen failed: ", err)
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+func (n *PgNotifier) listen(ctx context.Context) error {
+	conn, err := n.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		event := models.Event{}
+		if err = easyjson.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			continue
+		}
+		n.hub.Publish(event)
+	}
+}
@@ -1,30 +1,65 @@
 package handler
 
 import (
-	"encoding/json"
 	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/events"
 	"github.com/DESOLATE17/Database-term-project/internal/pkg/forum"
 	"github.com/DESOLATE17/Database-term-project/internal/utils"
 	"github.com/gorilla/mux"
-	"github.com/mailru/easyjson"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 )
 
 type Handler struct {
-	uc forum.UseCase
+	uc  forum.UseCase
+	hub *events.Hub
 }
 
-func NewForumHandler(ForumUseCase forum.UseCase) *Handler {
-	return &Handler{uc: ForumUseCase}
+func NewForumHandler(ForumUseCase forum.UseCase, hub *events.Hub) *Handler {
+	return &Handler{uc: ForumUseCase, hub: hub}
+}
+
+// listBody is the body of a list page: the bare items, or a models.Page with
+// the cursors when the request has a cursor parameter.
+func listBody(r *http.Request, items interface{}, cursors models.Cursors) interface{} {
+	if _, ok := r.URL.Query()["cursor"]; !ok {
+		return items
+	}
+	return models.Page{Items: items, NextCursor: cursors.Next, PrevCursor: cursors.Prev}
+}
+
+// setCursors exposes the cursors of a list page in X-Next-Cursor/X-Prev-Cursor and
+// as next/prev links to the same request with the cursor in place of since.
+func setCursors(w http.ResponseWriter, r *http.Request, cursors models.Cursors) {
+	var links []string
+	for _, c := range []struct{ rel, header, cursor string }{
+		{"next", "X-Next-Cursor", cursors.Next},
+		{"prev", "X-Prev-Cursor", cursors.Prev},
+	} {
+		if c.cursor == "" {
+			continue
+		}
+		w.Header().Set(c.header, c.cursor)
+		link := *r.URL
+		query := link.Query()
+		query.Del("since")
+		query.Del("desc")
+		query.Set("cursor", c.cursor)
+		link.RawQuery = query.Encode()
+		links = append(links, "<"+link.String()+`>; rel="`+c.rel+`"`)
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
 }
 
 func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	nickname, found := vars["nickname"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 
@@ -33,7 +68,7 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 
 	finalUser, err := h.uc.GetUser(r.Context(), userS)
 	if err != nil {
-		utils.Response(w, http.StatusNotFound, nickname)
+		utils.Error(w, err)
 		return
 	}
 	utils.Response(w, http.StatusOK, finalUser)
@@ -44,58 +79,67 @@ func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	nickname, found := vars["nickname"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 
 	user := models.User{}
-	_ = easyjson.UnmarshalFromReader(r.Body, &user)
+	if utils.Invalid(w, utils.Decode(r, &user)) {
+		return
+	}
 	user.NickName = nickname
+	// Roles are granted by admins only, never through the profile.
+	user.Role = ""
+	if utils.Invalid(w, user.Validate()) {
+		return
+	}
 
 	finalUser, err := h.uc.CreateUser(r.Context(), user)
-	if err == nil {
-		newU := finalUser[0]
-		utils.Response(w, http.StatusCreated, newU)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
-	utils.Response(w, http.StatusConflict, finalUser)
+	utils.Response(w, http.StatusCreated, finalUser[0])
 }
 
 func (h *Handler) ChangeUserInfo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	nickname, found := vars["nickname"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 
 	user := models.User{}
-	_ = easyjson.UnmarshalFromReader(r.Body, &user)
+	if utils.Invalid(w, utils.Decode(r, &user)) {
+		return
+	}
 	user.NickName = nickname
-
-	updatedUser, err := h.uc.UpdateUserInfo(r.Context(), user)
-	if err == nil {
-		utils.Response(w, http.StatusOK, updatedUser)
+	user.Role = ""
+	if utils.Invalid(w, user.ValidateUpdate()) {
 		return
 	}
-	if err == models.NotFound {
-		utils.Response(w, http.StatusNotFound, nickname)
+
+	updatedUser, err := h.uc.UpdateUserInfo(r.Context(), user)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
-	utils.Response(w, http.StatusConflict, models.ErrorResponse{Message: nickname})
+	utils.Response(w, http.StatusOK, updatedUser)
 }
 
 func (h *Handler) CreateForum(w http.ResponseWriter, r *http.Request) {
 	forum := models.Forum{}
-	_ = easyjson.UnmarshalFromReader(r.Body, &forum)
-
-	finalForum, err := h.uc.CreateForum(r.Context(), forum)
-	if err == models.Conflict {
-		utils.Response(w, http.StatusConflict, finalForum)
+	if utils.Invalid(w, utils.Decode(r, &forum)) {
+		return
+	}
+	if utils.Invalid(w, forum.Validate()) {
 		return
 	}
-	if err == models.NotFound {
-		utils.Response(w, http.StatusNotFound, forum.User)
+
+	finalForum, err := h.uc.CreateForum(r.Context(), forum)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
 	utils.Response(w, http.StatusCreated, finalForum)
@@ -105,34 +149,92 @@ func (h *Handler) ForumInfo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slug, found := vars["slug"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 
 	forum, err := h.uc.ForumInfo(r.Context(), slug)
-	if err == models.NotFound {
-		utils.Response(w, http.StatusNotFound, slug)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
 	utils.Response(w, http.StatusOK, forum)
 }
 
+func (h *Handler) GetForumTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.uc.GetForumTree(r.Context())
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, tree)
+}
+
+func (h *Handler) UpdateForum(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slug, found := vars["slug"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	forum := models.Forum{}
+	if utils.Invalid(w, utils.Decode(r, &forum)) {
+		return
+	}
+	forum.Slug = slug
+	if utils.Invalid(w, forum.ValidateUpdate()) {
+		return
+	}
+
+	updatedForum, err := h.uc.UpdateForum(r.Context(), forum)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, updatedForum)
+}
+
+func (h *Handler) DeleteForum(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slug, found := vars["slug"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	err := h.uc.DeleteForum(r.Context(), slug)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, nil)
+}
+
 func (h *Handler) CreatePosts(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, found := vars["slug_or_id"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 
 	thread, err := h.uc.CheckThreadIdOrSlug(r.Context(), slugOrId)
 	if err != nil {
-		utils.Response(w, http.StatusNotFound, slugOrId)
+		utils.Error(w, err)
 		return
 	}
 	var posts []models.Post
-	decoder := json.NewDecoder(r.Body)
-	_ = decoder.Decode(&posts)
+	if utils.Invalid(w, utils.DecodeList(r, &posts)) {
+		return
+	}
+	v := models.Validator{}
+	for i, post := range posts {
+		v.Merge("["+strconv.Itoa(i)+"].", post.Validate())
+	}
+	if utils.Invalid(w, v.Err()) {
+		return
+	}
 
 	if len(posts) == 0 {
 		utils.Response(w, http.StatusCreated, []models.Post{})
@@ -140,15 +242,10 @@ func (h *Handler) CreatePosts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	posts, err = h.uc.CreatePosts(r.Context(), posts, thread)
-	if err == models.NotFound {
-		utils.Response(w, http.StatusNotFound, slugOrId)
-		return
-	}
-	if err == models.Conflict {
-		utils.Response(w, http.StatusConflict, models.ErrorResponse{Message: "Parent post was created in another thread"})
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
-
 	utils.Response(w, http.StatusCreated, posts)
 }
 
@@ -156,21 +253,22 @@ func (h *Handler) CreateForumThread(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slug, found := vars["slug"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 
 	thread := models.Thread{}
-	_ = easyjson.UnmarshalFromReader(r.Body, &thread)
+	if utils.Invalid(w, utils.Decode(r, &thread)) {
+		return
+	}
 	thread.Forum = slug
-
-	thread, err := h.uc.CreateForumThread(r.Context(), thread)
-	if err == models.Conflict {
-		utils.Response(w, http.StatusConflict, thread)
+	if utils.Invalid(w, thread.Validate()) {
 		return
 	}
-	if err == models.NotFound {
-		utils.Response(w, http.StatusNotFound, models.ErrorResponse{Message: slug})
+
+	thread, err := h.uc.CreateForumThread(r.Context(), thread)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
 	utils.Response(w, http.StatusCreated, thread)
@@ -180,14 +278,17 @@ func (h *Handler) ThreadInfo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, found := vars["slug_or_id"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 	finalThread, err := h.uc.CheckThreadIdOrSlug(r.Context(), slugOrId)
-	if err == models.NotFound {
-		utils.Response(w, http.StatusNotFound, slugOrId)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
+	if r.URL.Query().Get("format") == "html" {
+		finalThread = h.uc.RenderThread(finalThread)
+	}
 	utils.Response(w, http.StatusOK, finalThread)
 }
 
@@ -195,7 +296,7 @@ func (h *Handler) GetPostsOfThread(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, found := vars["slug_or_id"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 	params := models.SortParams{}
@@ -203,59 +304,79 @@ func (h *Handler) GetPostsOfThread(w http.ResponseWriter, r *http.Request) {
 	params.Limit = r.URL.Query().Get("limit")
 	params.Since = r.URL.Query().Get("since")
 	params.Sort = r.URL.Query().Get("sort")
+	params.Cursor = r.URL.Query().Get("cursor")
+	if utils.Invalid(w, params.Validate("flat", "tree", "parent_tree", "score")) {
+		return
+	}
 
 	thread, err := h.uc.CheckThreadIdOrSlug(r.Context(), slugOrId)
-	if err == models.NotFound {
-		utils.Response(w, http.StatusNotFound, slugOrId)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
 
-	finalPosts, err := h.uc.GetPostOfThread(r.Context(), params, thread.ID)
-	if err == nil {
-		utils.Response(w, http.StatusOK, finalPosts)
+	finalPosts, cursors, err := h.uc.GetPostOfThread(r.Context(), params, thread.ID)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
-	utils.Response(w, http.StatusNotFound, slugOrId)
+	if r.URL.Query().Get("format") == "html" {
+		finalPosts = h.uc.RenderPosts(r.Context(), finalPosts)
+	}
+	setCursors(w, r, cursors)
+	utils.Response(w, http.StatusOK, listBody(r, finalPosts, cursors))
 }
 
 func (h *Handler) GetForumThreads(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slug, found := vars["slug"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 	params := models.SortParams{}
 	params.Desc = r.URL.Query().Get("desc")
 	params.Limit = r.URL.Query().Get("limit")
 	params.Since = r.URL.Query().Get("since")
+	params.Sort = r.URL.Query().Get("sort")
+	params.Cursor = r.URL.Query().Get("cursor")
+	if utils.Invalid(w, params.Validate("created", "votes", "activity", "replies")) {
+		return
+	}
 
 	forumS := models.Forum{Slug: slug}
 
-	threads, err := h.uc.GetForumThreads(r.Context(), forumS, params)
-	if err == nil {
-		utils.Response(w, http.StatusOK, threads)
+	threads, cursors, err := h.uc.GetForumThreads(r.Context(), forumS, params)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
-	utils.Response(w, http.StatusNotFound, slug)
+	setCursors(w, r, cursors)
+	utils.Response(w, http.StatusOK, listBody(r, threads, cursors))
 }
 
 func (h *Handler) Vote(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, found := vars["slug_or_id"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 
 	thread, err := h.uc.CheckThreadIdOrSlug(r.Context(), slugOrId)
 	if err != nil {
-		utils.Response(w, http.StatusNotFound, slugOrId)
+		utils.Error(w, err)
 		return
 	}
 
 	vote := models.Vote{}
-	_ = easyjson.UnmarshalFromReader(r.Body, &vote)
+	if utils.Invalid(w, utils.Decode(r, &vote)) {
+		return
+	}
+	// A voice of 0 retracts the vote.
+	if vote.Voice != 0 && utils.Invalid(w, vote.Validate()) {
+		return
+	}
 
 	if thread.ID != 0 {
 		vote.Thread = thread.ID
@@ -263,7 +384,7 @@ func (h *Handler) Vote(w http.ResponseWriter, r *http.Request) {
 
 	err = h.uc.Vote(r.Context(), vote)
 	if err != nil {
-		utils.Response(w, http.StatusNotFound, slugOrId)
+		utils.Error(w, err)
 		return
 	}
 
@@ -271,54 +392,238 @@ func (h *Handler) Vote(w http.ResponseWriter, r *http.Request) {
 	utils.Response(w, http.StatusOK, finalThread)
 }
 
+func (h *Handler) RetractVote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slugOrId, found := vars["slug_or_id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	thread, err := h.uc.CheckThreadIdOrSlug(r.Context(), slugOrId)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+
+	err = h.uc.RetractVote(r.Context(), models.Vote{Thread: thread.ID})
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+
+	finalThread, _ := h.uc.CheckThreadIdOrSlug(r.Context(), slugOrId)
+	utils.Response(w, http.StatusOK, finalThread)
+}
+
+func (h *Handler) GetVotes(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slugOrId, found := vars["slug_or_id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	votes, err := h.uc.GetVotes(r.Context(), slugOrId)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, votes)
+}
+
 func (h *Handler) UpdateThreadInfo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slugOrId, found := vars["slug_or_id"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 	thread := models.Thread{}
-	_ = easyjson.UnmarshalFromReader(r.Body, &thread)
+	if utils.Invalid(w, utils.Decode(r, &thread)) {
+		return
+	}
+	if utils.Invalid(w, thread.ValidateUpdate()) {
+		return
+	}
 	finalThread, err := h.uc.UpdateThreadInfo(r.Context(), slugOrId, thread)
-	if err == nil {
-		utils.Response(w, http.StatusOK, finalThread)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
-	utils.Response(w, http.StatusNotFound, slugOrId)
+	utils.Response(w, http.StatusOK, finalThread)
+}
+
+func (h *Handler) CloseThread(w http.ResponseWriter, r *http.Request) {
+	h.setThreadClosed(w, r, true)
+}
+
+func (h *Handler) ReopenThread(w http.ResponseWriter, r *http.Request) {
+	h.setThreadClosed(w, r, false)
+}
+
+func (h *Handler) setThreadClosed(w http.ResponseWriter, r *http.Request, closed bool) {
+	vars := mux.Vars(r)
+	slugOrId, found := vars["slug_or_id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	finalThread, err := h.uc.CloseThread(r.Context(), slugOrId, closed)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, finalThread)
+}
+
+func (h *Handler) PinThread(w http.ResponseWriter, r *http.Request) {
+	h.setThreadPinned(w, r, true)
+}
+
+func (h *Handler) UnpinThread(w http.ResponseWriter, r *http.Request) {
+	h.setThreadPinned(w, r, false)
+}
+
+func (h *Handler) setThreadPinned(w http.ResponseWriter, r *http.Request, pinned bool) {
+	vars := mux.Vars(r)
+	slugOrId, found := vars["slug_or_id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	finalThread, err := h.uc.PinThread(r.Context(), slugOrId, pinned)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, finalThread)
+}
+
+func (h *Handler) MoveThread(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slugOrId, found := vars["slug_or_id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	target := models.Thread{}
+	if utils.Invalid(w, utils.Decode(r, &target)) {
+		return
+	}
+	v := models.Validator{}
+	v.Required("forum", target.Forum)
+	v.Slug("forum", target.Forum)
+	if utils.Invalid(w, v.Err()) {
+		return
+	}
+
+	finalThread, err := h.uc.MoveThread(r.Context(), slugOrId, target.Forum)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, finalThread)
+}
+
+func (h *Handler) MergeThreads(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slugOrId, found := vars["slug_or_id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	source := r.URL.Query().Get("from")
+	v := models.Validator{}
+	v.Required("from", source)
+	if utils.Invalid(w, v.Err()) {
+		return
+	}
+
+	finalThread, err := h.uc.MergeThreads(r.Context(), slugOrId, source)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, finalThread)
+}
+
+func (h *Handler) SplitThread(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	ids, found := vars["id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	id, _ := strconv.Atoi(ids)
+
+	thread := models.Thread{}
+	if utils.Invalid(w, utils.Decode(r, &thread)) {
+		return
+	}
+	if utils.Invalid(w, thread.ValidateSplit()) {
+		return
+	}
+
+	finalThread, err := h.uc.SplitThread(r.Context(), id, thread)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusCreated, finalThread)
+}
+
+func (h *Handler) DeleteThread(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slugOrId, found := vars["slug_or_id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	err := h.uc.DeleteThread(r.Context(), slugOrId)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, nil)
 }
 
 func (h *Handler) GetUsersOfForum(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	slug, found := vars["slug"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 	params := models.SortParams{}
 	params.Desc = r.URL.Query().Get("desc")
 	params.Limit = r.URL.Query().Get("limit")
 	params.Since = r.URL.Query().Get("since")
+	params.Cursor = r.URL.Query().Get("cursor")
 
 	if params.Limit == "" {
 		params.Limit = "100"
 	}
+	if utils.Invalid(w, params.Validate("nickname")) {
+		return
+	}
 
 	forum := models.Forum{Slug: slug}
 
-	users, err := h.uc.GetUsersOfForum(r.Context(), forum, params)
-	if err == nil {
-		utils.Response(w, http.StatusOK, users)
+	users, cursors, err := h.uc.GetUsersOfForum(r.Context(), forum, params)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
-	utils.Response(w, http.StatusNotFound, slug)
+	setCursors(w, r, cursors)
+	utils.Response(w, http.StatusOK, listBody(r, users, cursors))
 }
 
 func (h *Handler) GetPostInfo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	idV, found := vars["id"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 
@@ -334,23 +639,35 @@ func (h *Handler) GetPostInfo(w http.ResponseWriter, r *http.Request) {
 
 	postFull.Post.ID = id
 	finalPost, err := h.uc.GetFullPostInfo(r.Context(), postFull, related)
-	if err == nil {
-		utils.Response(w, http.StatusOK, finalPost)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
-	utils.Response(w, http.StatusNotFound, id)
+	if query.Get("format") == "html" {
+		finalPost.Post = h.uc.RenderPosts(r.Context(), []models.Post{finalPost.Post})[0]
+		if finalPost.Thread != nil {
+			thread := h.uc.RenderThread(*finalPost.Thread)
+			finalPost.Thread = &thread
+		}
+	}
+	utils.Response(w, http.StatusOK, finalPost)
 }
 
 func (h *Handler) UpdatePostInfo(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	ids, found := vars["id"]
 	if !found {
-		utils.Response(w, http.StatusNotFound, nil)
+		utils.Error(w, models.NotFound)
 		return
 	}
 
 	postUpdate := models.PostUpdate{}
-	_ = easyjson.UnmarshalFromReader(r.Body, &postUpdate)
+	if utils.Invalid(w, utils.Decode(r, &postUpdate)) {
+		return
+	}
+	if utils.Invalid(w, postUpdate.Validate()) {
+		return
+	}
 
 	id, err := strconv.Atoi(ids)
 
@@ -359,15 +676,109 @@ func (h *Handler) UpdatePostInfo(w http.ResponseWriter, r *http.Request) {
 	}
 
 	finalPost, err := h.uc.UpdatePostInfo(r.Context(), postUpdate)
-	if err == nil {
-		utils.Response(w, http.StatusOK, finalPost)
+	if err != nil {
+		utils.Error(w, err)
 		return
 	}
-	utils.Response(w, http.StatusNotFound, id)
+	utils.Response(w, http.StatusOK, finalPost)
+}
+
+func (h *Handler) GetPostRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	ids, found := vars["id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	id, _ := strconv.Atoi(ids)
+
+	revisions, err := h.uc.GetPostRevisions(r.Context(), id)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, revisions)
+}
+
+func (h *Handler) GetPostDiff(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	ids, found := vars["id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	id, _ := strconv.Atoi(ids)
+	from, _ := strconv.Atoi(r.URL.Query().Get("from"))
+	to, _ := strconv.Atoi(r.URL.Query().Get("to"))
+	mode := r.URL.Query().Get("mode")
+
+	diff, err := h.uc.GetPostDiff(r.Context(), id, from, to, mode)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, diff)
+}
+
+func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	ids, found := vars["id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	id, _ := strconv.Atoi(ids)
+
+	deletedPost, err := h.uc.DeletePost(r.Context(), id)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, deletedPost)
+}
+
+func (h *Handler) RestorePost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	ids, found := vars["id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	id, _ := strconv.Atoi(ids)
+
+	restoredPost, err := h.uc.RestorePost(r.Context(), id)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, restoredPost)
+}
+
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	v := models.Validator{}
+	v.Int("limit", query.Get("limit"), 1, models.MaxLimit)
+	if utils.Invalid(w, v.Err()) {
+		return
+	}
+	limit, _ := strconv.Atoi(query.Get("limit"))
+
+	result, err := h.uc.Search(r.Context(), query.Get("q"), query.Get("forum"), query.Get("author"),
+		limit, query.Get("cursor"))
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	setCursors(w, r, models.Cursors{Next: result.NextCursor})
+	utils.Response(w, http.StatusOK, result)
 }
 
 func (h *Handler) GetClear(w http.ResponseWriter, r *http.Request) {
-	h.uc.GetClear(r.Context())
+	err := h.uc.GetClear(r.Context())
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
 	utils.Response(w, http.StatusOK, nil)
 }
 
@@ -375,3 +786,211 @@ func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
 	status := h.uc.GetStatus(r.Context())
 	utils.Response(w, http.StatusOK, status)
 }
+
+func (h *Handler) GetForumModerators(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slug, found := vars["slug"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	moderators, err := h.uc.GetForumModerators(r.Context(), slug)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, moderators)
+}
+
+func (h *Handler) AddForumModerator(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slug, found := vars["slug"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	user := models.User{}
+	if utils.Invalid(w, utils.Decode(r, &user)) {
+		return
+	}
+	v := models.Validator{}
+	v.Required("nickname", user.NickName)
+	v.Nickname("nickname", user.NickName)
+	if utils.Invalid(w, v.Err()) {
+		return
+	}
+
+	moderators, err := h.uc.AddForumModerator(r.Context(), slug, user.NickName)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, moderators)
+}
+
+func (h *Handler) RemoveForumModerator(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slug, found := vars["slug"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	nickname := vars["nickname"]
+
+	moderators, err := h.uc.RemoveForumModerator(r.Context(), slug, nickname)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, moderators)
+}
+
+func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	nickname, found := vars["nickname"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	v := models.Validator{}
+	v.Int("limit", query.Get("limit"), 1, models.MaxLimit)
+	v.Int("since", query.Get("since"), 1, math.MaxInt32)
+	v.OneOf("unread", query.Get("unread"), "true", "false")
+	if utils.Invalid(w, v.Err()) {
+		return
+	}
+	params := models.NotificationParams{Unread: query.Get("unread") == "true"}
+	params.Limit, _ = strconv.Atoi(query.Get("limit"))
+	params.Since, _ = strconv.Atoi(query.Get("since"))
+
+	notifications, err := h.uc.GetNotifications(r.Context(), nickname, params)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, notifications)
+}
+
+func (h *Handler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	nickname := vars["nickname"]
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	notification, err := h.uc.MarkNotificationRead(r.Context(), nickname, id)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, notification)
+}
+
+func (h *Handler) MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	nickname, found := vars["nickname"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	err := h.uc.MarkAllNotificationsRead(r.Context(), nickname)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, nil)
+}
+
+func (h *Handler) GetMentions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	nickname, found := vars["nickname"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	params := models.SortParams{Limit: query.Get("limit"), Since: query.Get("since")}
+	if utils.Invalid(w, params.Validate()) {
+		return
+	}
+
+	posts, err := h.uc.GetMentions(r.Context(), nickname, params)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, posts)
+}
+
+func (h *Handler) ToggleReaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	reaction := models.Reaction{}
+	if utils.Invalid(w, utils.Decode(r, &reaction)) {
+		return
+	}
+	if utils.Invalid(w, reaction.Validate()) {
+		return
+	}
+
+	post, err := h.uc.ToggleReaction(r.Context(), id, reaction.Emoji)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, post)
+}
+
+func (h *Handler) GetReactions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	reactions, err := h.uc.GetReactions(r.Context(), id)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, reactions)
+}
+
+func (h *Handler) VotePost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	vote := models.Vote{}
+	if utils.Invalid(w, utils.Decode(r, &vote)) {
+		return
+	}
+	if utils.Invalid(w, vote.Validate()) {
+		return
+	}
+	vote.Post = id
+
+	post, err := h.uc.VotePost(r.Context(), vote)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, post)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/utils"
+	"github.com/gorilla/mux"
+	"github.com/mailru/easyjson"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	streamBuffer    = 256
+	streamHeartbeat = 15 * time.Second
+	// streamBacklog bounds the missed posts replayed on reconnect.
+	streamBacklog = 1000
+)
+
+// StreamThread pushes the posts created in a thread as Server-Sent Events. The
+// event id is the post id, so a client reconnecting with Last-Event-ID first
+// receives the posts it has missed. A client that missed more than
+// streamBacklog posts gets a resync event instead, carrying the id of the
+// newest post: it should reload the thread's posts and keep listening.
+func (h *Handler) StreamThread(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slugOrId, found := vars["slug_or_id"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	thread, err := h.uc.CheckThreadIdOrSlug(r.Context(), slugOrId)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.Error(w, models.Describe(models.InternalError, "", "", "Streaming is not supported"))
+		return
+	}
+
+	lastID, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	if lastID == 0 {
+		lastID, _ = strconv.Atoi(r.URL.Query().Get("lastEventId"))
+	}
+
+	// Subscribe before loading the backlog so nothing is lost in between.
+	sub := h.hub.Subscribe(streamBuffer, func(event models.Event) bool {
+		return event.Type == models.EventPostCreated && event.Thread == thread.ID
+	})
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if lastID > 0 {
+		params := models.SortParams{Sort: "flat", Since: strconv.Itoa(lastID), Limit: strconv.Itoa(streamBacklog + 1)}
+		missed, _, err := h.uc.GetPostOfThread(r.Context(), params, thread.ID)
+		if err != nil {
+			return
+		}
+		if len(missed) > streamBacklog {
+			params = models.SortParams{Sort: "flat", Desc: "true", Limit: "1"}
+			newest, _, err := h.uc.GetPostOfThread(r.Context(), params, thread.ID)
+			if err != nil || len(newest) == 0 {
+				return
+			}
+			lastID = newest[0].ID
+			_, _ = fmt.Fprintf(w, "id: %d\nevent: resync\ndata: {}\n\n", lastID)
+			missed = nil
+		}
+		for _, post := range missed {
+			data, err := easyjson.Marshal(post)
+			if err != nil {
+				return
+			}
+			writeSSE(w, post.ID, data)
+			lastID = post.ID
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, _ = fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case event, open := <-sub.C:
+			if !open {
+				// Dropped for being too slow, the client resumes from its Last-Event-ID.
+				return
+			}
+			if event.ID <= lastID {
+				continue
+			}
+			writeSSE(w, event.ID, event.Data)
+			lastID = event.ID
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, id int, data []byte) {
+	_, _ = fmt.Fprintf(w, "id: %d\nevent: post\ndata: %s\n\n", id, data)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/gorilla/websocket"
+	"github.com/mailru/easyjson"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	wsBuffer       = 256
+	wsWriteTimeout = 10 * time.Second
+	wsPongTimeout  = 60 * time.Second
+	wsPingPeriod   = wsPongTimeout * 9 / 10
+	wsMaxMessage   = 4096
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// wsSubscriptions is the set of forums and users a WebSocket client follows.
+type wsSubscriptions struct {
+	mu     sync.RWMutex
+	forums map[string]struct{}
+	users  map[string]struct{}
+}
+
+func (s *wsSubscriptions) apply(command models.Subscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, forum := range command.Forums {
+		if command.Action == models.UnsubscribeAction {
+			delete(s.forums, strings.ToLower(forum))
+		} else {
+			s.forums[strings.ToLower(forum)] = struct{}{}
+		}
+	}
+	for _, user := range command.Users {
+		if command.Action == models.UnsubscribeAction {
+			delete(s.users, strings.ToLower(user))
+		} else {
+			s.users[strings.ToLower(user)] = struct{}{}
+		}
+	}
+}
+
+func (s *wsSubscriptions) accepts(event models.Event) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.forums[strings.ToLower(event.Forum)]; ok {
+		return true
+	}
+	_, ok := s.users[strings.ToLower(event.Author)]
+	return ok
+}
+
+// Gateway upgrades the connection to a WebSocket pushing forum activity as JSON
+// frames. Clients send {"action": "subscribe"|"unsubscribe", "forums": [...],
+// "users": [...]} to choose what they receive. A client that can't keep up is
+// disconnected instead of slowing down everyone else.
+func (h *Handler) Gateway(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	subscriptions := &wsSubscriptions{forums: make(map[string]struct{}), users: make(map[string]struct{})}
+	sub := h.hub.Subscribe(wsBuffer, subscriptions.accepts)
+	defer sub.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadLimit(wsMaxMessage)
+		_ = conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		})
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			command := models.Subscription{}
+			if easyjson.Unmarshal(message, &command) != nil {
+				continue
+			}
+			subscriptions.apply(command)
+		}
+	}()
+
+	ping := time.NewTicker(wsPingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ping.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if conn.WriteMessage(websocket.PingMessage, nil) != nil {
+				return
+			}
+		case event, open := <-sub.C:
+			_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if !open {
+				_ = conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "client is too slow"))
+				return
+			}
+			frame, err := easyjson.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if conn.WriteMessage(websocket.TextMessage, frame) != nil {
+				return
+			}
+		}
+	}
+}
@@ -11,18 +11,46 @@ type UseCase interface {
 	UpdateUserInfo(ctx context.Context, user models.User) (models.User, error)
 	CreateForum(ctx context.Context, forum models.Forum) (models.Forum, error)
 	ForumInfo(ctx context.Context, slug string) (models.Forum, error)
+	GetForumTree(ctx context.Context) ([]models.ForumNode, error)
+	UpdateForum(ctx context.Context, forum models.Forum) (models.Forum, error)
+	DeleteForum(ctx context.Context, slug string) error
 	CheckThreadIdOrSlug(ctx context.Context, slugOrId string) (models.Thread, error)
 	CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, error)
 	CreateForumThread(ctx context.Context, thread models.Thread) (models.Thread, error)
-	GetPostOfThread(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, error)
-	GetForumThreads(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.Thread, error)
+	GetPostOfThread(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, models.Cursors, error)
+	GetForumThreads(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.Thread, models.Cursors, error)
 	Vote(ctx context.Context, vote models.Vote) error
+	RetractVote(ctx context.Context, vote models.Vote) error
+	GetVotes(ctx context.Context, slugOrId string) ([]models.Vote, error)
+	VotePost(ctx context.Context, vote models.Vote) (models.Post, error)
 	UpdateThreadInfo(ctx context.Context, slugOrId string, updateThread models.Thread) (models.Thread, error)
-	GetUsersOfForum(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.User, error)
+	CloseThread(ctx context.Context, slugOrId string, closed bool) (models.Thread, error)
+	PinThread(ctx context.Context, slugOrId string, pinned bool) (models.Thread, error)
+	MoveThread(ctx context.Context, slugOrId string, slug string) (models.Thread, error)
+	MergeThreads(ctx context.Context, targetSlugOrId string, sourceSlugOrId string) (models.Thread, error)
+	SplitThread(ctx context.Context, postID int, thread models.Thread) (models.Thread, error)
+	DeleteThread(ctx context.Context, slugOrId string) error
+	GetUsersOfForum(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.User, models.Cursors, error)
 	GetFullPostInfo(ctx context.Context, posts models.PostFull, related []string) (models.PostFull, error)
 	UpdatePostInfo(ctx context.Context, postUpdate models.PostUpdate) (models.Post, error)
+	GetPostRevisions(ctx context.Context, id int) ([]models.PostRevision, error)
+	GetPostDiff(ctx context.Context, id int, from int, to int, mode string) (models.PostDiff, error)
+	DeletePost(ctx context.Context, id int) (models.Post, error)
+	RestorePost(ctx context.Context, id int) (models.Post, error)
+	Search(ctx context.Context, query string, forum string, author string, limit int, cursor string) (models.SearchResult, error)
+	GetNotifications(ctx context.Context, nickname string, params models.NotificationParams) ([]models.Notification, error)
+	MarkNotificationRead(ctx context.Context, nickname string, id int) (models.Notification, error)
+	MarkAllNotificationsRead(ctx context.Context, nickname string) error
+	GetMentions(ctx context.Context, nickname string, params models.SortParams) ([]models.Post, error)
+	ToggleReaction(ctx context.Context, id int, emoji string) (models.Post, error)
+	GetReactions(ctx context.Context, id int) ([]models.Reaction, error)
+	RenderPosts(ctx context.Context, posts []models.Post) []models.Post
+	RenderThread(thread models.Thread) models.Thread
 	GetStatus(ctx context.Context) models.Status
-	GetClear(ctx context.Context)
+	GetClear(ctx context.Context) error
+	GetForumModerators(ctx context.Context, slug string) ([]models.User, error)
+	AddForumModerator(ctx context.Context, slug string, nickname string) ([]models.User, error)
+	RemoveForumModerator(ctx context.Context, slug string, nickname string) ([]models.User, error)
 }
 
 type Repository interface {
@@ -32,6 +60,14 @@ type Repository interface {
 	UpdateUserInfo(ctx context.Context, user models.User) (models.User, error)
 	CreateForum(ctx context.Context, forum models.Forum) error
 	GetForum(ctx context.Context, slug string) (models.Forum, error)
+	GetForums(ctx context.Context) ([]models.Forum, error)
+	GetForumTotals(ctx context.Context, slug string) (int, int, error)
+	UpdateForum(ctx context.Context, forum models.Forum) (models.Forum, error)
+	DeleteForum(ctx context.Context, slug string) error
+	IsForumModerator(ctx context.Context, slug string, nickname string) (bool, error)
+	GetForumModerators(ctx context.Context, slug string) ([]models.User, error)
+	AddForumModerator(ctx context.Context, slug string, nickname string) error
+	RemoveForumModerator(ctx context.Context, slug string, nickname string) error
 	GetThreadBySlug(ctx context.Context, slug string) (models.Thread, error)
 	GetThreadByID(ctx context.Context, id int) (models.Thread, error)
 	CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, error)
@@ -39,14 +75,44 @@ type Repository interface {
 	GetPostsFlat(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, error)
 	GetPostsTree(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, error)
 	GetPostsParent(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, error)
+	GetPostsScore(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, error)
 	GetForumThreads(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.Thread, error)
+	GetPinnedThreads(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.Thread, error)
 	ForumCheck(ctx context.Context, slug string) (string, error)
 	Vote(ctx context.Context, vote models.Vote) error
 	UpdateVote(ctx context.Context, vote models.Vote) error
+	RetractVote(ctx context.Context, vote models.Vote) error
+	GetVotes(ctx context.Context, threadID int) ([]models.Vote, error)
+	VotePost(ctx context.Context, vote models.Vote) error
 	UpdateThreadInfo(ctx context.Context, upThread models.Thread) (models.Thread, error)
+	SetThreadClosed(ctx context.Context, id int, closed bool) (models.Thread, error)
+	SetThreadPinned(ctx context.Context, id int, pinned bool) (models.Thread, error)
+	DeleteThread(ctx context.Context, thread models.Thread) error
+	MoveThread(ctx context.Context, thread models.Thread, slug string) (models.Thread, error)
+	MergeThreads(ctx context.Context, target models.Thread, source models.Thread) (models.Thread, error)
+	SplitThread(ctx context.Context, post models.Post, thread models.Thread) (models.Thread, error)
 	GetUsersOfForum(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.User, error)
 	GetFullPostInfo(ctx context.Context, posts models.PostFull, related []string) (models.PostFull, error)
 	UpdatePostInfo(ctx context.Context, postUpdate models.PostUpdate) (models.Post, error)
+	GetPostRevisions(ctx context.Context, id int) ([]models.PostRevision, error)
+	SetPostDeleted(ctx context.Context, id int, deleted bool) (models.Post, error)
+	Search(ctx context.Context, params models.SearchParams) ([]models.SearchHit, error)
+	ToggleReaction(ctx context.Context, reaction models.Reaction) (bool, error)
+	GetReactions(ctx context.Context, id int) ([]models.Reaction, error)
+	GetReactionCounts(ctx context.Context, ids []int) (map[int]map[string]int, error)
+	GetPostsHTML(ctx context.Context, ids []int) (map[int]string, error)
+	SetPostsHTML(ctx context.Context, rendered map[int]string) error
+	SetPostMentions(ctx context.Context, mentions map[int][]string) error
+	GetMentions(ctx context.Context, nickname string, params models.SortParams) ([]models.Post, error)
+	CreateNotifications(ctx context.Context, posts []models.Post) error
+	GetNotifications(ctx context.Context, nickname string, params models.NotificationParams) ([]models.Notification, error)
+	MarkNotificationRead(ctx context.Context, nickname string, id int) (models.Notification, error)
+	MarkAllNotificationsRead(ctx context.Context, nickname string) error
 	GetStatus(ctx context.Context) models.Status
 	GetClear(ctx context.Context)
 }
+
+// Publisher delivers domain events to live subscribers.
+type Publisher interface {
+	Publish(event models.Event)
+}
@@ -0,0 +1,113 @@
+package repo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// query assembles a statement from trusted SQL fragments and bound values.
+// Fragments mark values with ?, which are numbered into $n parameters when the
+// statement is rendered, so user input only ever reaches the database as an
+// argument. Column names and other identifiers must come from the code, never
+// from the request.
+type query struct {
+	sql   strings.Builder
+	args  []interface{}
+	where bool
+}
+
+func newQuery(sql string, args ...interface{}) *query {
+	return (&query{}).add(sql, args...)
+}
+
+// add appends a fragment whose ? placeholders take args in order.
+func (q *query) add(sql string, args ...interface{}) *query {
+	if q.sql.Len() > 0 && !strings.HasPrefix(sql, ",") && !strings.HasPrefix(sql, ")") {
+		q.sql.WriteByte(' ')
+	}
+	q.sql.WriteString(sql)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// addQuery appends another query, e.g. a subquery, together with its values.
+func (q *query) addQuery(sub *query) *query {
+	return q.add(sub.sql.String(), sub.args...)
+}
+
+// and appends a condition, opening the WHERE clause on the first one.
+func (q *query) and(cond string, args ...interface{}) *query {
+	if q.where {
+		return q.add("AND "+cond, args...)
+	}
+	q.where = true
+	return q.add("WHERE "+cond, args...)
+}
+
+// after appends the keyset condition of a page: key strictly after bound in the
+// listing order, i.e. below it when desc.
+func (q *query) after(key string, desc bool, bound string, args ...interface{}) *query {
+	if desc {
+		return q.and(key+" < "+bound, args...)
+	}
+	return q.and(key+" > "+bound, args...)
+}
+
+// orderBy appends an ORDER BY on columns, all ascending or all descending.
+func (q *query) orderBy(desc bool, columns ...string) *query {
+	order := " ASC"
+	if desc {
+		order = " DESC"
+	}
+	return q.add("ORDER BY " + strings.Join(columns, order+", ") + order)
+}
+
+// limit appends a LIMIT unless limit is empty.
+func (q *query) limit(limit string) *query {
+	if limit == "" {
+		return q
+	}
+	return q.add("LIMIT ?", limit)
+}
+
+// values appends a VALUES list of rows of n values each, taken from args.
+func (q *query) values(n int, args ...interface{}) *query {
+	row := "(?" + strings.Repeat(", ?", n-1) + ")"
+	rows := make([]string, 0, len(args)/n)
+	for i := 0; i < len(args); i += n {
+		rows = append(rows, row)
+	}
+	return q.add("VALUES "+strings.Join(rows, ", "), args...)
+}
+
+// String renders the statement with numbered parameters. A ? inside a quoted
+// literal or identifier is left as it is; elsewhere every ? is a placeholder,
+// so operators spelled with ? (the jsonb ?, ?| and ?&) can't be used in
+// fragments, call jsonb_exists and its siblings instead. Dollar-quoted and
+// backslash-escaped E'...' strings are not recognized either.
+func (q *query) String() string {
+	sql := q.sql.String()
+	var b strings.Builder
+	b.Grow(len(sql) + len(q.args))
+	n := 0
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			// A doubled quote inside a quoted section closes and reopens it.
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQueryString(t *testing.T) {
+	tests := []struct {
+		name  string
+		query *query
+		sql   string
+		args  []interface{}
+	}{
+		{
+			name:  "conditions, order and limit",
+			query: newQuery("SELECT id FROM post").and("thread = ?", 4).after("id", false, "?", "10").orderBy(false, "id").limit("5"),
+			sql:   "SELECT id FROM post WHERE thread = $1 AND id > $2 ORDER BY id ASC LIMIT $3",
+			args:  []interface{}{4, "10", "5"},
+		},
+		{
+			name:  "descending keyset with a cast",
+			query: newQuery("SELECT id FROM post").and("thread = ?", 4).after("path", true, "?::INTEGER[]", "{1,7}").orderBy(true, "path", "id"),
+			sql:   "SELECT id FROM post WHERE thread = $1 AND path < $2::INTEGER[] ORDER BY path DESC, id DESC",
+			args:  []interface{}{4, "{1,7}"},
+		},
+		{
+			name: "tuple comparison",
+			query: newQuery("SELECT id FROM thread").and("forum = ?", "go").
+				after("(votes, id)", false, "(?::INTEGER, ?::INTEGER)", "3", "42"),
+			sql:  "SELECT id FROM thread WHERE forum = $1 AND (votes, id) > ($2::INTEGER, $3::INTEGER)",
+			args: []interface{}{"go", "3", "42"},
+		},
+		{
+			name:  "question marks in quotes",
+			query: newQuery(`SELECT '?', "a?b", 'it''s ?' FROM t`).and("x = ?", 1).and(`y <> 'z?'`).and("w = ?", 2),
+			sql:   `SELECT '?', "a?b", 'it''s ?' FROM t WHERE x = $1 AND y <> 'z?' AND w = $2`,
+			args:  []interface{}{1, 2},
+		},
+		{
+			name:  "subquery and empty limit",
+			query: newQuery("SELECT id FROM post").and("id = ANY (").addQuery(newQuery("SELECT id FROM post").and("parent = ?", 0)).add(")").limit(""),
+			sql:   "SELECT id FROM post WHERE id = ANY ( SELECT id FROM post WHERE parent = $1)",
+			args:  []interface{}{0},
+		},
+		{
+			name:  "values",
+			query: newQuery("INSERT INTO vote(author, voice)").values(2, "a", 1, "b", -1),
+			sql:   "INSERT INTO vote(author, voice) VALUES ($1, $2), ($3, $4)",
+			args:  []interface{}{"a", 1, "b", -1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sql := tt.query.String(); sql != tt.sql {
+				t.Errorf("sql = %q, want %q", sql, tt.sql)
+			}
+			if !reflect.DeepEqual(tt.query.args, tt.args) {
+				t.Errorf("args = %v, want %v", tt.query.args, tt.args)
+			}
+		})
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"html"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -27,6 +29,8 @@ func convertPgErr(err error) error {
 			return models.NotFound
 		case "23505": // unique constraint
 			return models.Conflict
+		case "22P02", "22007", "22008", "22003": // malformed or out of range value
+			return models.BadRequest
 		default:
 			return models.InternalError
 		}
@@ -34,17 +38,27 @@ func convertPgErr(err error) error {
 	return nil
 }
 
+// queryErr maps the error of a list query. Values the database can't cast,
+// like the key of a tampered cursor, are the client's fault; anything else is
+// an internal error.
+func queryErr(err error) error {
+	if converted := convertPgErr(err); converted == models.BadRequest {
+		return converted
+	}
+	return models.InternalError
+}
+
 func (r *repoPostgres) GetUser(ctx context.Context, name string) (models.User, error) {
 	var userM models.User
 	const (
-		SelectUserByNickname = `SELECT nickname, fullname, about, email
+		SelectUserByNickname = `SELECT nickname, fullname, about, email, role
 								FROM users WHERE nickname=$1
 								LIMIT 1;`
 	)
 	row := r.Conn.QueryRow(ctx, SelectUserByNickname, name)
-	err := row.Scan(&userM.NickName, &userM.FullName, &userM.About, &userM.Email)
+	err := row.Scan(&userM.NickName, &userM.FullName, &userM.About, &userM.Email, &userM.Role)
 	if err != nil {
-		return models.User{}, models.NotFound
+		return models.User{}, models.Wrap(models.NotFound, models.ResourceUser, name)
 	}
 	return userM, nil
 }
@@ -73,8 +87,8 @@ func (r *repoPostgres) CheckUserEmailAndNicknameUniq(ctx context.Context, user m
 }
 
 func (r *repoPostgres) CreateUser(ctx context.Context, user models.User) error {
-	const CreateUser = `INSERT INTO users(Nickname, FullName, About, Email) VALUES ($1, $2, $3, $4);`
-	_, err := r.Conn.Exec(ctx, CreateUser, user.NickName, user.FullName, user.About, user.Email)
+	const CreateUser = `INSERT INTO users(Nickname, FullName, About, Email, Password) VALUES ($1, $2, $3, $4, $5);`
+	_, err := r.Conn.Exec(ctx, CreateUser, user.NickName, user.FullName, user.About, user.Email, user.Password)
 	if err != nil {
 		return models.InternalError
 	}
@@ -84,51 +98,234 @@ func (r *repoPostgres) UpdateUserInfo(ctx context.Context, user models.User) (mo
 	const (
 		UpdateUserInfo = `UPDATE users
 						  SET fullname=coalesce(nullif($1, ''), fullname), about=coalesce(nullif($2, ''), about), email=coalesce(nullif($3, ''), email)
-						  WHERE nickname=$4 RETURNING *`
+						  WHERE nickname=$4 RETURNING nickname, fullname, about, email, role`
 	)
 	updatedUser := models.User{}
 	row := r.Conn.QueryRow(ctx, UpdateUserInfo, user.FullName, user.About, user.Email, user.NickName)
-	err := row.Scan(&updatedUser.NickName, &updatedUser.FullName, &updatedUser.About, &updatedUser.Email)
+	err := row.Scan(&updatedUser.NickName, &updatedUser.FullName, &updatedUser.About, &updatedUser.Email, &updatedUser.Role)
 	if err == pgx.ErrNoRows {
-		return updatedUser, models.NotFound
+		return updatedUser, models.Wrap(models.NotFound, models.ResourceUser, user.NickName)
 	}
 	return updatedUser, convertPgErr(err)
 }
 
 func (r *repoPostgres) CreateForum(ctx context.Context, forum models.Forum) error {
 	const (
-		CreateForum = `INSERT INTO forum(slug, "user", title) VALUES ($1, $2, $3);`
+		CreateForum = `INSERT INTO forum(slug, "user", title, parent) VALUES ($1, $2, $3, nullif($4, ''));`
 	)
-	_, err := r.Conn.Exec(ctx, CreateForum, forum.Slug, forum.User, forum.Title)
+	_, err := r.Conn.Exec(ctx, CreateForum, forum.Slug, forum.User, forum.Title, forum.Parent)
 	return convertPgErr(err)
 }
 
 func (r *repoPostgres) GetForum(ctx context.Context, slug string) (models.Forum, error) {
 	const (
-		GetForumBySlug = `SELECT title, "user", slug, posts, threads
+		GetForumBySlug = `SELECT title, "user", slug, posts, threads, coalesce(parent, '')
 						  FROM forum WHERE slug=$1
 						  LIMIT 1;`
 	)
 	forum := models.Forum{}
 	row := r.Conn.QueryRow(ctx, GetForumBySlug, slug)
-	err := row.Scan(&forum.Title, &forum.User, &forum.Slug, &forum.Posts, &forum.Threads)
+	err := row.Scan(&forum.Title, &forum.User, &forum.Slug, &forum.Posts, &forum.Threads, &forum.Parent)
 	if err != nil {
-		return forum, models.NotFound
+		return forum, models.Wrap(models.NotFound, models.ResourceForum, slug)
 	}
 	return forum, nil
 }
 
+func (r *repoPostgres) UpdateForum(ctx context.Context, forum models.Forum) (models.Forum, error) {
+	const (
+		UpdateForum = `UPDATE forum SET title=coalesce(nullif($1, ''), title), "user"=coalesce(nullif($2, ''), "user")
+					   WHERE slug=$3 RETURNING title, "user", slug, posts, threads, coalesce(parent, '');`
+	)
+	updated := models.Forum{}
+	row := r.Conn.QueryRow(ctx, UpdateForum, forum.Title, forum.User, forum.Slug)
+	err := row.Scan(&updated.Title, &updated.User, &updated.Slug, &updated.Posts, &updated.Threads, &updated.Parent)
+	if err != nil {
+		return models.Forum{}, models.Wrap(models.NotFound, models.ResourceForum, forum.Slug)
+	}
+	return updated, nil
+}
+
+// DeleteForum removes a forum with everything posted in it and takes its
+// threads and live posts off the status counters.
+func (r *repoPostgres) DeleteForum(ctx context.Context, slug string) error {
+	const (
+		CountThreads    = `SELECT count(*) FROM thread WHERE forum=$1;`
+		CountLivePosts  = `SELECT count(*) FROM post WHERE forum=$1 AND NOT isdeleted;`
+		DeleteRevisions = `DELETE FROM post_revision
+						   WHERE post IN (SELECT id FROM post WHERE forum=$1);`
+		DeleteNotifications = `DELETE FROM notification
+							   WHERE post IN (SELECT id FROM post WHERE forum=$1);`
+		DeleteMentions = `DELETE FROM post_mention
+						  WHERE post IN (SELECT id FROM post WHERE forum=$1);`
+		DeleteReactions = `DELETE FROM post_reaction
+						   WHERE post IN (SELECT id FROM post WHERE forum=$1);`
+		DeletePostVotes = `DELETE FROM post_vote
+						   WHERE post IN (SELECT id FROM post WHERE forum=$1);`
+		DeleteVotes = `DELETE FROM vote
+					   WHERE thread IN (SELECT id FROM thread WHERE forum=$1);`
+		DeletePosts      = `DELETE FROM post WHERE forum=$1;`
+		DeleteThreads    = `DELETE FROM thread WHERE forum=$1;`
+		DeleteUsersForum = `DELETE FROM users_forum WHERE slug=$1;`
+		DeleteModerators = `DELETE FROM forum_moderator WHERE slug=$1;`
+		ReparentChildren = `UPDATE forum SET parent=(SELECT parent FROM forum WHERE slug=$1) WHERE parent=$1;`
+		DeleteForum      = `DELETE FROM forum WHERE slug=$1;`
+		UpdateStatus     = `UPDATE status SET forums=forums - 1, threads=threads - $1, posts=posts - $2 WHERE id=1;`
+	)
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return models.InternalError
+	}
+	defer tx.Rollback(ctx)
+
+	var threads, livePosts int
+	if err = tx.QueryRow(ctx, CountThreads, slug).Scan(&threads); err != nil {
+		return models.InternalError
+	}
+	if err = tx.QueryRow(ctx, CountLivePosts, slug).Scan(&livePosts); err != nil {
+		return models.InternalError
+	}
+
+	for _, query := range []string{DeleteRevisions, DeleteNotifications, DeleteMentions, DeleteReactions, DeletePostVotes, DeleteVotes, DeletePosts, DeleteThreads, DeleteUsersForum,
+		DeleteModerators, ReparentChildren} {
+		if _, err = tx.Exec(ctx, query, slug); err != nil {
+			return models.InternalError
+		}
+	}
+	tag, err := tx.Exec(ctx, DeleteForum, slug)
+	if err != nil {
+		return models.InternalError
+	}
+	if tag.RowsAffected() == 0 {
+		return models.Wrap(models.NotFound, models.ResourceForum, slug)
+	}
+	if _, err = tx.Exec(ctx, UpdateStatus, threads, livePosts); err != nil {
+		return models.InternalError
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.InternalError
+	}
+	return nil
+}
+
+func (r *repoPostgres) GetForums(ctx context.Context) ([]models.Forum, error) {
+	const (
+		SelectForums = `SELECT title, "user", slug, posts, threads, coalesce(parent, '')
+						FROM forum ORDER BY slug;`
+	)
+	rows, err := r.Conn.Query(ctx, SelectForums)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+
+	forums := make([]models.Forum, 0)
+	for rows.Next() {
+		forum := models.Forum{}
+		err = rows.Scan(&forum.Title, &forum.User, &forum.Slug, &forum.Posts, &forum.Threads, &forum.Parent)
+		if err != nil {
+			return nil, models.InternalError
+		}
+		forums = append(forums, forum)
+	}
+	return forums, nil
+}
+
+// GetForumTotals sums posts and threads of a forum and all of its subforums.
+func (r *repoPostgres) GetForumTotals(ctx context.Context, slug string) (int, int, error) {
+	const (
+		SelectTotals = `WITH RECURSIVE subtree AS (
+							SELECT slug, posts, threads FROM forum WHERE slug=$1
+							UNION ALL
+							SELECT f.slug, f.posts, f.threads FROM forum f JOIN subtree s ON f.parent = s.slug
+						)
+						SELECT coalesce(sum(posts), 0), coalesce(sum(threads), 0) FROM subtree;`
+	)
+	var posts, threads int
+	err := r.Conn.QueryRow(ctx, SelectTotals, slug).Scan(&posts, &threads)
+	if err != nil {
+		return 0, 0, models.InternalError
+	}
+	return posts, threads, nil
+}
+
+func (r *repoPostgres) IsForumModerator(ctx context.Context, slug string, nickname string) (bool, error) {
+	const (
+		SelectModerator = `SELECT EXISTS(SELECT 1 FROM forum_moderator WHERE slug=$1 AND nickname=$2);`
+	)
+	var found bool
+	err := r.Conn.QueryRow(ctx, SelectModerator, slug, nickname).Scan(&found)
+	if err != nil {
+		return false, models.InternalError
+	}
+	return found, nil
+}
+
+func (r *repoPostgres) GetForumModerators(ctx context.Context, slug string) ([]models.User, error) {
+	const (
+		SelectModerators = `SELECT u.nickname, u.fullname, u.about, u.email
+							FROM forum_moderator m JOIN users u ON u.nickname = m.nickname
+							WHERE m.slug=$1
+							ORDER BY u.nickname;`
+	)
+	rows, err := r.Conn.Query(ctx, SelectModerators, slug)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		user := models.User{}
+		err = rows.Scan(&user.NickName, &user.FullName, &user.About, &user.Email)
+		if err != nil {
+			return nil, models.InternalError
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (r *repoPostgres) AddForumModerator(ctx context.Context, slug string, nickname string) error {
+	const (
+		InsertModerator = `INSERT INTO forum_moderator(slug, nickname) VALUES ($1, $2)
+						   ON CONFLICT DO NOTHING;`
+	)
+	_, err := r.Conn.Exec(ctx, InsertModerator, slug, nickname)
+	return convertPgErr(err)
+}
+
+func (r *repoPostgres) RemoveForumModerator(ctx context.Context, slug string, nickname string) error {
+	const (
+		DeleteModerator = `DELETE FROM forum_moderator WHERE slug=$1 AND nickname=$2;`
+	)
+	tag, err := r.Conn.Exec(ctx, DeleteModerator, slug, nickname)
+	if err != nil {
+		return models.InternalError
+	}
+	if tag.RowsAffected() == 0 {
+		return models.Wrap(models.NotFound, models.ResourceUser, nickname)
+	}
+	return nil
+}
+
+// GetThreadBySlug finds a thread by its slug. Threads created without one
+// share the empty slug, so an empty slug never names a thread.
 func (r *repoPostgres) GetThreadBySlug(ctx context.Context, slug string) (models.Thread, error) {
 	thread := models.Thread{}
 	const (
-		GetThreadBySlug = `SELECT id, title, author, forum, message, votes, slug, created
+		GetThreadBySlug = `SELECT id, title, author, forum, message, votes, slug, created, isclosed, ispinned, replies, lastpostat
 						   	FROM thread WHERE slug=$1 LIMIT 1;`
 	)
+	if slug == "" {
+		return thread, models.Wrap(models.NotFound, models.ResourceThread, slug)
+	}
 	row := r.Conn.QueryRow(ctx, GetThreadBySlug, slug)
 	err := row.Scan(&thread.ID, &thread.Title, &thread.Author, &thread.Forum,
-		&thread.Message, &thread.Votes, &thread.Slug, &thread.Created)
+		&thread.Message, &thread.Votes, &thread.Slug, &thread.Created, &thread.Closed, &thread.Pinned, &thread.Replies, &thread.LastPostAt)
 	if err != nil {
-		return models.Thread{}, models.NotFound
+		return models.Thread{}, models.Wrap(models.NotFound, models.ResourceThread, slug)
 	}
 	return thread, nil
 }
@@ -136,7 +333,7 @@ func (r *repoPostgres) GetThreadBySlug(ctx context.Context, slug string) (models
 func (r *repoPostgres) GetThreadByID(ctx context.Context, id int) (models.Thread, error) {
 	thread := models.Thread{}
 	const (
-		GetThreadById = `SELECT id, title, author, forum, message, votes, slug, created
+		GetThreadById = `SELECT id, title, author, forum, message, votes, slug, created, isclosed, ispinned, replies, lastpostat
 						 FROM thread WHERE id=$1
 						 LIMIT 1;`
 	)
@@ -144,288 +341,364 @@ func (r *repoPostgres) GetThreadByID(ctx context.Context, id int) (models.Thread
 	row := r.Conn.QueryRow(ctx, GetThreadById, id)
 
 	err := row.Scan(&thread.ID, &thread.Title, &thread.Author, &thread.Forum,
-		&thread.Message, &thread.Votes, &thread.Slug, &thread.Created)
+		&thread.Message, &thread.Votes, &thread.Slug, &thread.Created, &thread.Closed, &thread.Pinned, &thread.Replies, &thread.LastPostAt)
 	if err != nil {
-		return models.Thread{}, models.NotFound
+		return models.Thread{}, models.Wrap(models.NotFound, models.ResourceThread, id)
 	}
 	return thread, nil
 }
 
+// tombstone hides the content of a soft-deleted post while keeping its place in the tree.
+func tombstone(post *models.Post) {
+	if post.IsDeleted {
+		post.Author = ""
+		post.Message = models.DeletedPostMessage
+	}
+}
+
+// CreatePosts inserts the posts into the thread. The thread row is locked
+// first, so the posts land in the forum the thread is in when they commit even
+// while the thread is being moved.
 func (r *repoPostgres) CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, error) {
-	query := "INSERT INTO post(author, created, forum, message, parent, thread) VALUES "
-	values := make([]interface{}, 0)
-	created := time.Now()
+	const (
+		LockThread         = `SELECT forum FROM thread WHERE id=$1 FOR UPDATE;`
+		SelectParentThread = `SELECT thread
+							  FROM post WHERE id = $1;`
+		UpdateThreadActivity = `UPDATE thread SET replies=replies + $2, lastpostat=greatest(lastpostat, $3)
+								WHERE id=$1;`
+		InsertNotifications = `INSERT INTO notification (nickname, kind, post, thread, forum, author, created)
+							   SELECT parent.author, 'reply', p.id, p.thread, p.forum, p.author, p.created
+							   FROM post p JOIN post parent ON parent.id = p.parent
+							   WHERE p.id = ANY ($1) AND parent.author <> p.author
+							   UNION ALL
+							   SELECT t.author, 'thread', p.id, p.thread, p.forum, p.author, p.created
+							   FROM post p JOIN thread t ON t.id = p.thread
+							   WHERE p.id = ANY ($1) AND p.parent = 0 AND t.author <> p.author
+							   ON CONFLICT DO NOTHING;`
+	)
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer tx.Rollback(ctx)
+
+	if err = tx.QueryRow(ctx, LockThread, thread.ID).Scan(&thread.Forum); err != nil {
+		return nil, models.Wrap(models.NotFound, models.ResourceThread, thread.ID)
+	}
 
-	for i, post := range posts {
-		query += fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d),", i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6)
+	values := make([]interface{}, 0, len(posts)*6)
+	created := time.Now()
+	for _, post := range posts {
 		values = append(values, post.Author, created, thread.Forum, post.Message, post.Parent, thread.ID)
 		if post.Parent != 0 {
 			old := 0
-			query2 := `SELECT thread 
-					   FROM post WHERE id = $1;`
-			row := r.Conn.QueryRow(ctx, query2, post.Parent)
-			err := row.Scan(&old)
+			err = tx.QueryRow(ctx, SelectParentThread, post.Parent).Scan(&old)
 			if err != nil || old != thread.ID {
-				return []models.Post{}, models.Conflict
+				return []models.Post{}, models.Describe(models.Conflict, models.ResourcePost, post.Parent, "Parent post was created in another thread")
 			}
 		}
 	}
+	q := newQuery("INSERT INTO post(author, created, forum, message, parent, thread)").values(6, values...)
+	q.add("RETURNING id, created, forum, isEdited, thread")
 
-	query = strings.TrimSuffix(query, ",")
-	query += ` RETURNING id, created, forum, isEdited, thread;`
-
-	rows, err := r.Conn.Query(ctx, query, values...)
+	rows, err := tx.Query(ctx, q.String(), q.args...)
 	if err != nil {
 		return nil, convertPgErr(err)
 	}
-	defer rows.Close()
-
 	for i := range posts {
 		if rows.Next() {
 			err = rows.Scan(&posts[i].ID, &posts[i].Created, &posts[i].Forum, &posts[i].IsEdited, &posts[i].Thread)
 			if err != nil {
+				rows.Close()
 				return nil, convertPgErr(err)
 			}
 		}
 	}
+	rows.Close()
 	if rows.Err() != nil {
 		return nil, convertPgErr(rows.Err())
 	}
-	return posts, convertPgErr(err)
+
+	if _, err = tx.Exec(ctx, UpdateThreadActivity, thread.ID, len(posts), created); err != nil {
+		return nil, models.InternalError
+	}
+	ids := make([]int, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	if _, err = tx.Exec(ctx, InsertNotifications, ids); err != nil {
+		return nil, models.InternalError
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return nil, models.InternalError
+	}
+	return posts, nil
 }
 func (r *repoPostgres) CreateThread(ctx context.Context, thread models.Thread) (models.Thread, error) {
 	const (
-		InsertThread = `INSERT INTO thread (author, message, title, created, forum, slug, votes)
-						VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id;`
+		InsertThread = `INSERT INTO thread (author, message, title, created, forum, slug, votes, lastpostat)
+						VALUES ($1, $2, $3, $4, $5, $6, $7, $4) RETURNING id, lastpostat;`
 	)
 	row := r.Conn.QueryRow(ctx, InsertThread, thread.Author, thread.Message, thread.Title,
 		thread.Created, thread.Forum, thread.Slug, 0)
-	err := row.Scan(&thread.ID)
+	err := row.Scan(&thread.ID, &thread.LastPostAt)
 	return thread, convertPgErr(err)
 }
 
 func (r *repoPostgres) GetPostsFlat(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, error) {
-	var rows pgx.Rows
-	query := `SELECT id, author, created, forum, isedited, message, parent, thread
-			  FROM post
-		      WHERE thread = $1 `
-
-	if params.Limit == "" && params.Since == "" {
-		if params.Desc == "true" {
-			query += ` ORDER BY id DESC`
-		} else {
-			query += ` ORDER BY id ASC`
-		}
-		rows, _ = r.Conn.Query(ctx, query, threadID)
-	} else {
-		if params.Limit != "" && params.Since == "" {
-			if params.Desc == "true" {
-				query += ` ORDER BY id DESC LIMIT $2`
-			} else {
-				query += `ORDER BY id ASC LIMIT $2`
-			}
-			rows, _ = r.Conn.Query(ctx, query, threadID, params.Limit)
-		}
-
-		if params.Limit != "" && params.Since != "" {
-			if params.Desc == "true" {
-				query += `AND id < $2 ORDER BY id DESC LIMIT $3`
-			} else {
-				query += `AND id > $2 ORDER BY id ASC LIMIT $3`
-			}
-			rows, _ = r.Conn.Query(ctx, query, threadID, params.Since, params.Limit)
-		}
+	desc := params.Desc == "true"
+	q := newQuery(`SELECT id, author, created, forum, isedited, message, parent, thread, isdeleted, score
+				   FROM post`).and("thread = ?", threadID)
+	if params.Since != "" {
+		q.after("id", desc, "?", params.Since)
+	}
+	q.orderBy(desc, "id").limit(params.Limit)
 
-		if params.Limit == "" && params.Since != "" {
-			if params.Desc == "true" {
-				query += `AND id < $2 ORDER BY id DESC`
-			} else {
-				query += `AND id > $2 ORDER BY id ASC`
-			}
-			rows, _ = r.Conn.Query(ctx, query, threadID, params.Since)
-		}
+	rows, err := r.Conn.Query(ctx, q.String(), q.args...)
+	if err != nil {
+		return nil, models.InternalError
 	}
-	posts := make([]models.Post, 0)
 	defer rows.Close()
+	posts := make([]models.Post, 0)
 	for rows.Next() {
 		onePost := models.Post{}
-		err := rows.Scan(&onePost.ID, &onePost.Author, &onePost.Created, &onePost.Forum, &onePost.IsEdited, &onePost.Message, &onePost.Parent, &onePost.Thread)
+		err = rows.Scan(&onePost.ID, &onePost.Author, &onePost.Created, &onePost.Forum, &onePost.IsEdited, &onePost.Message, &onePost.Parent, &onePost.Thread, &onePost.IsDeleted, &onePost.Score)
 		if err != nil {
 			return posts, models.InternalError
 		}
+		tombstone(&onePost)
 		posts = append(posts, onePost)
 	}
+	if rows.Err() != nil {
+		return nil, models.InternalError
+	}
 	return posts, nil
 }
 
 func (r *repoPostgres) GetPostsTree(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, error) {
-
-	var rows pgx.Rows
-
-	query := `SELECT id, author, created, forum, isedited, message, parent, thread
-			  FROM post
-			  WHERE thread = $1 `
-
-	if params.Limit == "" && params.Since == "" {
-		if params.Desc == "true" {
-			query += `ORDER BY path, id DESC`
-		} else {
-			query += `ORDER BY path, id ASC`
-		}
-		rows, _ = r.Conn.Query(ctx, query, threadID)
-	} else {
-		if params.Limit != "" && params.Since == "" {
-			if params.Desc == "true" {
-				query += ` ORDER BY path DESC, id DESC LIMIT $2`
-			} else {
-				query += ` ORDER BY path, id ASC LIMIT $2`
-			}
-			rows, _ = r.Conn.Query(ctx, query, threadID, params.Limit)
-		}
-
-		if params.Limit != "" && params.Since != "" {
-			if params.Desc == "true" {
-				query = `SELECT post.id, post.author, post.created, 
-				post.forum, post.isedited, post.message, post.parent, post.thread
-				FROM post JOIN post parent ON parent.id = $2 WHERE post.path < parent.path AND  post.thread = $1
-				ORDER BY post.path DESC, post.id DESC LIMIT $3`
-			} else {
-				query = `SELECT post.id, post.author, post.created, 
-				post.forum, post.isedited, post.message, post.parent, post.thread
-				FROM post JOIN post parent ON parent.id = $2 WHERE post.path > parent.path AND  post.thread = $1
-				ORDER BY post.path ASC, post.id ASC LIMIT $3`
-			}
-			rows, _ = r.Conn.Query(ctx, query, threadID, params.Since, params.Limit)
-		}
-
-		if params.Limit == "" && params.Since != "" {
-			if params.Desc == "true" {
-				query = `SELECT post.id, post.author, post.created, 
-				post.forum, post.isedited, post.message, post.parent, post.thread
-				FROM post JOIN post parent ON parent.id = $2 WHERE post.path < parent.path AND  post.thread = $1
-				ORDER BY post.path DESC, post.id DESC`
-			} else {
-				query = `SELECT post.id, post.author, post.created, 
-				post.forum, post.isedited, post.message, post.parent, post.thread
-				FROM post JOIN post parent ON parent.id = $2 WHERE post.path > parent.path AND  post.thread = $1
-				ORDER BY post.path ASC, post.id ASC`
-			}
-			rows, _ = r.Conn.Query(ctx, query, threadID, params.Since)
-		}
+	desc := params.Desc == "true"
+	q := newQuery(`SELECT id, author, created, forum, isedited, message, parent, thread, isdeleted, score, path::TEXT
+				   FROM post`).and("thread = ?", threadID)
+	switch {
+	case params.Key != "":
+		q.after("path", desc, "?::INTEGER[]", params.Key)
+	case params.Since != "":
+		q.after("path", desc, "(SELECT path FROM post WHERE id = ?)", params.Since)
 	}
+	q.orderBy(desc, "path", "id").limit(params.Limit)
 
-	posts := make([]models.Post, 0)
+	rows, err := r.Conn.Query(ctx, q.String(), q.args...)
+	if err != nil {
+		return nil, models.InternalError
+	}
 	defer rows.Close()
+	posts := make([]models.Post, 0)
 	for rows.Next() {
 		onePost := models.Post{}
-		err := rows.Scan(&onePost.ID, &onePost.Author, &onePost.Created, &onePost.Forum, &onePost.IsEdited, &onePost.Message, &onePost.Parent, &onePost.Thread)
+		err = rows.Scan(&onePost.ID, &onePost.Author, &onePost.Created, &onePost.Forum, &onePost.IsEdited, &onePost.Message, &onePost.Parent, &onePost.Thread, &onePost.IsDeleted, &onePost.Score, &onePost.Key)
 		if err != nil {
-			fmt.Println(err)
 			return posts, models.InternalError
 		}
+		tombstone(&onePost)
 		posts = append(posts, onePost)
 	}
-
+	if rows.Err() != nil {
+		return nil, models.InternalError
+	}
 	return posts, nil
 }
 
-func (r *repoPostgres) GetPostsParent(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, error) {
-	var rows pgx.Rows
-
-	parents := fmt.Sprintf(`SELECT id FROM post WHERE thread = %d AND parent = 0 `, threadID)
+// GetPostsScore returns the posts in tree order with siblings ranked by score,
+// higher first, and by id among equal scores. The rank of a post is the list of
+// (-score, id) pairs along its path, so ordering by it keeps every subtree
+// under its root; since continues after the given post in that order, and a
+// cursor's key after the rank the post had when the cursor was issued.
+func (r *repoPostgres) GetPostsScore(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, error) {
+	desc := params.Desc == "true"
+	q := newQuery(`WITH RECURSIVE ranked AS (
+					   SELECT id, ARRAY [-score, id] AS rank
+					   FROM post WHERE thread = ? AND parent = 0
+					   UNION ALL
+					   SELECT p.id, ranked.rank || ARRAY [-p.score, p.id]
+					   FROM post p JOIN ranked ON p.parent = ranked.id
+					   WHERE p.thread = ?)
+				   SELECT p.id, p.author, p.created, p.forum, p.isedited, p.message, p.parent, p.thread,
+						  p.isdeleted, p.score, ranked.rank::TEXT
+				   FROM ranked JOIN post p ON p.id = ranked.id`, threadID, threadID)
+	switch {
+	case params.Key != "":
+		q.after("ranked.rank", desc, "?::INTEGER[]", params.Key)
+	case params.Since != "":
+		q.after("ranked.rank", desc, "(SELECT rank FROM ranked WHERE id = ?)", params.Since)
+	}
+	q.orderBy(desc, "ranked.rank").limit(params.Limit)
 
-	if params.Since != "" {
-		if params.Desc == "true" {
-			parents += ` AND path[1] < ` + fmt.Sprintf(`(SELECT path[1] FROM post WHERE id = %s) `, params.Since)
-		} else {
-			parents += ` AND path[1] > ` + fmt.Sprintf(`(SELECT path[1] FROM post WHERE id = %s) `, params.Since)
+	rows, err := r.Conn.Query(ctx, q.String(), q.args...)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+	posts := make([]models.Post, 0)
+	for rows.Next() {
+		onePost := models.Post{}
+		err = rows.Scan(&onePost.ID, &onePost.Author, &onePost.Created, &onePost.Forum, &onePost.IsEdited, &onePost.Message, &onePost.Parent, &onePost.Thread, &onePost.IsDeleted, &onePost.Score, &onePost.Key)
+		if err != nil {
+			return posts, models.InternalError
 		}
+		tombstone(&onePost)
+		posts = append(posts, onePost)
 	}
-
-	if params.Desc == "true" {
-		parents += ` ORDER BY id DESC `
-	} else {
-		parents += ` ORDER BY id ASC `
+	if rows.Err() != nil {
+		return nil, models.InternalError
 	}
+	return posts, nil
+}
 
-	if params.Limit != "" {
-		parents += " LIMIT " + params.Limit
+// GetPostsParent pages over the root posts of the thread and returns each of
+// them followed by its whole subtree.
+func (r *repoPostgres) GetPostsParent(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, error) {
+	desc := params.Desc == "true"
+	parents := newQuery(`SELECT id FROM post`).and("thread = ?", threadID).and("parent = 0")
+	switch {
+	case params.Key != "":
+		parents.after("id", desc, "?", params.Key)
+	case params.Since != "":
+		parents.after("path[1]", desc, "(SELECT path[1] FROM post WHERE id = ?)", params.Since)
 	}
+	parents.orderBy(desc, "id").limit(params.Limit)
 
-	query := fmt.Sprintf(
-		`SELECT id, author, created, forum, isedited, message, parent, thread FROM post WHERE path[1] = ANY (%s) `, parents)
+	q := newQuery(`SELECT id, author, created, forum, isedited, message, parent, thread, isdeleted, score
+				   FROM post`).and("path[1] = ANY (").addQuery(parents).add(")")
+	q.orderBy(desc, "path[1]").add(", path, id")
 
-	if params.Desc == "true" {
-		query += ` ORDER BY path[1] DESC, path,  id `
-	} else {
-		query += ` ORDER BY path[1] ASC, path,  id `
+	rows, err := r.Conn.Query(ctx, q.String(), q.args...)
+	if err != nil {
+		return nil, models.InternalError
 	}
-
-	rows, _ = r.Conn.Query(ctx, query)
-	posts := make([]models.Post, 0)
 	defer rows.Close()
+	posts := make([]models.Post, 0)
 	for rows.Next() {
 		onePost := models.Post{}
-		err := rows.Scan(&onePost.ID, &onePost.Author, &onePost.Created, &onePost.Forum, &onePost.IsEdited, &onePost.Message, &onePost.Parent, &onePost.Thread)
+		err = rows.Scan(&onePost.ID, &onePost.Author, &onePost.Created, &onePost.Forum, &onePost.IsEdited, &onePost.Message, &onePost.Parent, &onePost.Thread, &onePost.IsDeleted, &onePost.Score)
 		if err != nil {
-			fmt.Println(err)
 			return posts, models.InternalError
 		}
+		tombstone(&onePost)
 		posts = append(posts, onePost)
 	}
-
+	if rows.Err() != nil {
+		return nil, models.InternalError
+	}
 	return posts, nil
 }
 
+// threadSortColumns maps the sort parameter of the thread list to its column.
+var threadSortColumns = map[string]string{
+	"votes":    "votes",
+	"activity": "lastpostat",
+	"replies":  "replies",
+}
+
+// threadSortTypes is the type cursor keys are compared as for each sort column.
+var threadSortTypes = map[string]string{
+	"created":    "TIMESTAMPTZ",
+	"votes":      "INTEGER",
+	"lastpostat": "TIMESTAMPTZ",
+	"replies":    "INTEGER",
+}
+
+// GetForumThreads lists the regular threads of the forum by creation time,
+// votes, last activity or replies, with ties broken by id. Cursor pages
+// continue after the (key, id) pair the cursor carries. Without a cursor, since
+// is a value of the sort column, a timestamp or a count, and the page starts at
+// it inclusively like the legacy created list.
 func (r *repoPostgres) GetForumThreads(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.Thread, error) {
-	var rows pgx.Rows
-	var err error
-	threads := make([]models.Thread, 0)
-	const (
-		GetThreadsSinceDescNotNil = `SELECT id, title, author, forum, message, votes, slug, created
-                                     	FROM thread WHERE forum=$1 AND created <= $2 
-                                        ORDER BY created DESC LIMIT $3;`
-		GetThreadsSinceDescNil = `SELECT id, title, author, forum, message, votes, slug, created
-                                     FROM thread WHERE forum=$1 AND created >= $2
-                                     ORDER BY created ASC  LIMIT $3;`
-		GetThreadsDescNotNil = `SELECT id, title, author, forum, message, votes, slug, created
-                                	FROM thread WHERE forum=$1
-                                	ORDER BY created DESC LIMIT $2;`
-		GetThreadsDescNil = `SELECT id, title, author, forum, message, votes, slug, created
-									FROM thread WHERE forum=$1
-			             			ORDER BY created ASC  LIMIT $2;`
-	)
+	column, ok := threadSortColumns[params.Sort]
+	if !ok {
+		column = "created"
+	}
+	desc := params.Desc == "true"
+	q := newQuery(`SELECT id, title, author, forum, message, votes, slug, created, isclosed, ispinned, replies, lastpostat
+				   FROM thread`).and("forum = ?", forum.Slug).and("NOT ispinned")
+	switch {
+	case params.Key != "":
+		q.after("("+column+", id)", desc, "(?::"+threadSortTypes[column]+", ?::INTEGER)", params.Key, params.Since)
+	case params.Since == "":
+	case desc:
+		q.and(column+" <= ?::"+threadSortTypes[column], params.Since)
+	default:
+		q.and(column+" >= ?::"+threadSortTypes[column], params.Since)
+	}
+	q.orderBy(desc, column, "id").limit(params.Limit)
 
-	if params.Since != "" {
-		if params.Desc == "true" {
-			rows, err = r.Conn.Query(ctx, GetThreadsSinceDescNotNil, forum.Slug, params.Since, params.Limit)
-		} else {
-			rows, err = r.Conn.Query(ctx, GetThreadsSinceDescNil, forum.Slug, params.Since, params.Limit)
-		}
-	} else {
-		if params.Desc == "true" {
-			rows, err = r.Conn.Query(ctx, GetThreadsDescNotNil, forum.Slug, params.Limit)
-		} else {
-			rows, err = r.Conn.Query(ctx, GetThreadsDescNil, forum.Slug, params.Limit)
+	threads := make([]models.Thread, 0)
+	rows, err := r.Conn.Query(ctx, q.String(), q.args...)
+	if err != nil {
+		return threads, queryErr(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		threadS := models.Thread{}
+		err = rows.Scan(&threadS.ID, &threadS.Title, &threadS.Author, &threadS.Forum, &threadS.Message,
+			&threadS.Votes, &threadS.Slug, &threadS.Created, &threadS.Closed, &threadS.Pinned, &threadS.Replies, &threadS.LastPostAt)
+		if err != nil {
+			return threads, models.InternalError
 		}
+		threads = append(threads, threadS)
 	}
+	if rows.Err() != nil {
+		return nil, queryErr(rows.Err())
+	}
+	return threads, nil
+}
 
+// GetPinnedThreads lists all pinned threads of the forum in the order of the
+// listing. They are few and not paged: limit and since don't apply to them.
+func (r *repoPostgres) GetPinnedThreads(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.Thread, error) {
+	column, ok := threadSortColumns[params.Sort]
+	if !ok {
+		column = "created"
+	}
+	q := newQuery(`SELECT id, title, author, forum, message, votes, slug, created, isclosed, ispinned, replies, lastpostat
+				   FROM thread`).and("forum = ?", forum.Slug).and("ispinned")
+	q.orderBy(params.Desc == "true", column, "id")
+
+	threads := make([]models.Thread, 0)
+	rows, err := r.Conn.Query(ctx, q.String(), q.args...)
 	if err != nil {
-		return threads, models.NotFound
+		return threads, queryErr(err)
 	}
 	defer rows.Close()
 	for rows.Next() {
 		threadS := models.Thread{}
 		err = rows.Scan(&threadS.ID, &threadS.Title, &threadS.Author, &threadS.Forum, &threadS.Message,
-			&threadS.Votes, &threadS.Slug, &threadS.Created)
+			&threadS.Votes, &threadS.Slug, &threadS.Created, &threadS.Closed, &threadS.Pinned, &threadS.Replies, &threadS.LastPostAt)
 		if err != nil {
-			continue
+			return threads, models.InternalError
 		}
 		threads = append(threads, threadS)
 	}
+	if rows.Err() != nil {
+		return nil, queryErr(rows.Err())
+	}
 	return threads, nil
 }
 
+func (r *repoPostgres) SetThreadPinned(ctx context.Context, id int, pinned bool) (models.Thread, error) {
+	const (
+		UpdateThreadPinned = `UPDATE thread SET ispinned=$1 WHERE id=$2
+							  RETURNING id, title, author, forum, message, votes, slug, created, isclosed, ispinned, replies, lastpostat;`
+	)
+	thread := models.Thread{}
+	row := r.Conn.QueryRow(ctx, UpdateThreadPinned, pinned, id)
+	err := row.Scan(&thread.ID, &thread.Title, &thread.Author, &thread.Forum,
+		&thread.Message, &thread.Votes, &thread.Slug, &thread.Created, &thread.Closed, &thread.Pinned, &thread.Replies, &thread.LastPostAt)
+	if err != nil {
+		return models.Thread{}, models.Wrap(models.NotFound, models.ResourceThread, id)
+	}
+	return thread, nil
+}
+
 func (r *repoPostgres) ForumCheck(ctx context.Context, slug string) (string, error) {
 	const (
 		SelectSlugFromForum = `SELECT slug
@@ -435,7 +708,7 @@ func (r *repoPostgres) ForumCheck(ctx context.Context, slug string) (string, err
 	row := r.Conn.QueryRow(ctx, SelectSlugFromForum, slug)
 	err := row.Scan(&slug)
 	if err != nil {
-		return slug, models.NotFound
+		return slug, models.Wrap(models.NotFound, models.ResourceForum, slug)
 	}
 	return slug, nil
 }
@@ -452,7 +725,7 @@ func (r *repoPostgres) Vote(ctx context.Context, vote models.Vote) error {
 
 func (r *repoPostgres) UpdateVote(ctx context.Context, vote models.Vote) error {
 	const (
-		UpdateVote = `UPDATE vote SET voice=$1 WHERE author=$2 AND thread=$3;`
+		UpdateVote = `UPDATE vote SET voice=$1, voted=now(), retracted=NULL WHERE author=$2 AND thread=$3;`
 	)
 
 	_, err := r.Conn.Exec(ctx, UpdateVote, vote.Voice, vote.Nickname, vote.Thread)
@@ -462,104 +735,574 @@ func (r *repoPostgres) UpdateVote(ctx context.Context, vote models.Vote) error {
 	return nil
 }
 
-func (r *repoPostgres) UpdateThreadInfo(ctx context.Context, upThread models.Thread) (models.Thread, error) {
-	threadS := models.Thread{}
-	var row pgx.Row
+// RetractVote marks the user's vote retracted and keeps it for the history; the
+// update_votes trigger takes its voice back from the thread. Retracting a vote
+// that doesn't exist or is already retracted is a no-op.
+func (r *repoPostgres) RetractVote(ctx context.Context, vote models.Vote) error {
 	const (
-		UpdateThread = `UPDATE thread SET title=coalesce(nullif($1, ''), title), message=coalesce(nullif($2, ''), message)
-                        WHERE %s RETURNING *;`
+		RetractVote = `UPDATE vote SET retracted=now() WHERE author=$1 AND thread=$2 AND retracted IS NULL;`
 	)
-
-	if upThread.Slug == "" {
-		rowQuery := fmt.Sprintf(UpdateThread, `id=$3`)
-		row = r.Conn.QueryRow(ctx, rowQuery, upThread.Title, upThread.Message, upThread.ID)
-	} else {
-		rowQuery := fmt.Sprintf(UpdateThread, `slug=$3`)
-		row = r.Conn.QueryRow(ctx, rowQuery, upThread.Title, upThread.Message, upThread.Slug)
-	}
-	err := row.Scan(&threadS.ID, &threadS.Title, &threadS.Author,
-		&threadS.Forum, &threadS.Message, &threadS.Votes, &threadS.Slug, &threadS.Created)
+	_, err := r.Conn.Exec(ctx, RetractVote, vote.Nickname, vote.Thread)
 	if err != nil {
-		return models.Thread{}, models.NotFound
+		return models.InternalError
 	}
-	return threadS, nil
+	return nil
 }
 
-func (r *repoPostgres) GetUsersOfForum(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.User, error) {
-	var query string
-	const (
-		GetUsersOfForumDescNotNilSince = `SELECT nickname, fullname, about, email
-										  FROM users_forum 
-										  WHERE slug=$1 AND nickname < '%s' 
-										  ORDER BY nickname DESC 
-										  LIMIT nullif($2, 0)`
-		GetUsersOfForumDescSinceNil = `SELECT nickname, fullname, about, email
-										  FROM users_forum
-										  WHERE slug=$1 
-										  ORDER BY nickname DESC
-										  LIMIT nullif($2, 0)`
-		GetUsersOfForumAsc = `SELECT nickname, fullname, about, email
-										  FROM users_forum 
-										  WHERE slug=$1 AND nickname > '%s' 
-										  ORDER BY nickname 
-										  LIMIT nullif($2, 0)`
-	)
-
-	if params.Desc == "true" {
-		if params.Since != "" {
-			query = fmt.Sprintf(GetUsersOfForumDescNotNilSince, params.Since)
-		} else {
-			query = GetUsersOfForumDescSinceNil
-		}
-	} else {
-		query = fmt.Sprintf(GetUsersOfForumAsc, params.Since)
-	}
-	users := make([]models.User, 0)
-	rows, err := r.Conn.Query(ctx, query, forum.Slug, params.Limit)
-
+func (r *repoPostgres) GetVotes(ctx context.Context, threadID int) ([]models.Vote, error) {
+	const (
+		SelectVotes = `SELECT author, voice, thread, voted, retracted
+					   FROM vote WHERE thread=$1
+					   ORDER BY voted, author;`
+	)
+	rows, err := r.Conn.Query(ctx, SelectVotes, threadID)
 	if err != nil {
-		return users, models.NotFound
+		return nil, models.InternalError
 	}
-
 	defer rows.Close()
 
+	votes := make([]models.Vote, 0)
 	for rows.Next() {
-		user := models.User{}
-		err = rows.Scan(&user.NickName, &user.FullName, &user.About, &user.Email)
-		if err != nil {
-			return users, models.InternalError
+		vote := models.Vote{}
+		if err = rows.Scan(&vote.Nickname, &vote.Voice, &vote.Thread, &vote.Voted, &vote.Retracted); err != nil {
+			return nil, models.InternalError
 		}
-		users = append(users, user)
+		votes = append(votes, vote)
 	}
-
-	return users, nil
+	if rows.Err() != nil {
+		return nil, models.InternalError
+	}
+	return votes, nil
 }
 
-func (r *repoPostgres) GetFullPostInfo(ctx context.Context, posts models.PostFull, related []string) (models.PostFull, error) {
-	post := models.Post{}
-	postFull := models.PostFull{}
-
+// VotePost sets the user's voice on a post and moves the post's score by the
+// difference to the previous voice. The post row is locked first, so concurrent
+// votes on one post are applied one after another.
+func (r *repoPostgres) VotePost(ctx context.Context, vote models.Vote) error {
 	const (
-		SelectPostById = `SELECT author, message, created, forum, isedited, parent, thread
-						  FROM post WHERE id = $1;`
+		LockPost   = `SELECT id FROM post WHERE id=$1 AND NOT isdeleted FOR UPDATE;`
+		SelectVote = `SELECT voice FROM post_vote WHERE post=$1 AND nickname=$2;`
+		UpsertVote = `INSERT INTO post_vote (post, nickname, voice)
+					  VALUES ($1, $2, $3)
+					  ON CONFLICT (post, nickname) DO UPDATE SET voice=excluded.voice, created=now();`
+		UpdateScore = `UPDATE post SET score=score + $2 WHERE id=$1;`
 	)
-
-	post.ID = posts.Post.ID
-
-	row := r.Conn.QueryRow(ctx, SelectPostById, posts.Post.ID)
-	err := row.Scan(&post.Author, &post.Message, &post.Created, &post.Forum, &post.IsEdited, &post.Parent, &post.Thread)
+	tx, err := r.Conn.Begin(ctx)
 	if err != nil {
-		return postFull, models.NotFound
+		return models.InternalError
 	}
+	defer tx.Rollback(ctx)
 
-	postFull.Post = post
-
-	for i := 0; i < len(related); i++ {
-		if "user" == related[i] {
-			user, _ := r.GetUser(ctx, post.Author)
-			postFull.Author = &user
-		}
-		if "forum" == related[i] {
+	if err = tx.QueryRow(ctx, LockPost, vote.Post).Scan(&vote.Post); err != nil {
+		return models.Wrap(models.NotFound, models.ResourcePost, vote.Post)
+	}
+	var previous int
+	err = tx.QueryRow(ctx, SelectVote, vote.Post, vote.Nickname).Scan(&previous)
+	if err != nil && err != pgx.ErrNoRows {
+		return models.InternalError
+	}
+	if previous == vote.Voice {
+		return nil
+	}
+	if _, err = tx.Exec(ctx, UpsertVote, vote.Post, vote.Nickname, vote.Voice); err != nil {
+		return convertPgErr(err)
+	}
+	if _, err = tx.Exec(ctx, UpdateScore, vote.Post, vote.Voice-previous); err != nil {
+		return models.InternalError
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return models.InternalError
+	}
+	return nil
+}
+
+func (r *repoPostgres) UpdateThreadInfo(ctx context.Context, upThread models.Thread) (models.Thread, error) {
+	threadS := models.Thread{}
+	q := newQuery(`UPDATE thread SET title=coalesce(nullif(?, ''), title), message=coalesce(nullif(?, ''), message)`,
+		upThread.Title, upThread.Message)
+	if upThread.Slug == "" {
+		q.and("id = ?", upThread.ID)
+	} else {
+		q.and("slug = ?", upThread.Slug)
+	}
+	q.add("RETURNING id, title, author, forum, message, votes, slug, created, isclosed, ispinned, replies, lastpostat")
+
+	row := r.Conn.QueryRow(ctx, q.String(), q.args...)
+	err := row.Scan(&threadS.ID, &threadS.Title, &threadS.Author,
+		&threadS.Forum, &threadS.Message, &threadS.Votes, &threadS.Slug, &threadS.Created, &threadS.Closed, &threadS.Pinned, &threadS.Replies, &threadS.LastPostAt)
+	if err != nil {
+		return models.Thread{}, models.NotFound
+	}
+	return threadS, nil
+}
+
+func (r *repoPostgres) SetThreadClosed(ctx context.Context, id int, closed bool) (models.Thread, error) {
+	const (
+		UpdateThreadClosed = `UPDATE thread SET isclosed=$1 WHERE id=$2
+							  RETURNING id, title, author, forum, message, votes, slug, created, isclosed, ispinned, replies, lastpostat;`
+	)
+	thread := models.Thread{}
+	row := r.Conn.QueryRow(ctx, UpdateThreadClosed, closed, id)
+	err := row.Scan(&thread.ID, &thread.Title, &thread.Author, &thread.Forum,
+		&thread.Message, &thread.Votes, &thread.Slug, &thread.Created, &thread.Closed, &thread.Pinned, &thread.Replies, &thread.LastPostAt)
+	if err != nil {
+		return models.Thread{}, models.Wrap(models.NotFound, models.ResourceThread, id)
+	}
+	return thread, nil
+}
+
+// cleanUsersForum drops the given users from users_forum of a forum once they
+// have neither threads nor posts left there.
+const cleanUsersForum = `DELETE FROM users_forum uf
+						 WHERE uf.slug=$1 AND uf.nickname = ANY ($2)
+						 AND NOT EXISTS(SELECT 1 FROM post p WHERE p.forum=uf.slug AND p.author=uf.nickname)
+						 AND NOT EXISTS(SELECT 1 FROM thread t WHERE t.forum=uf.slug AND t.author=uf.nickname);`
+
+// refreshThreadActivity recounts the replies and the last post time of a thread
+// whose posts were moved in or out wholesale.
+const refreshThreadActivity = `UPDATE thread
+							   SET replies=(SELECT count(*) FROM post WHERE thread=$1 AND NOT isdeleted),
+								   lastpostat=greatest(created, (SELECT max(created) FROM post WHERE thread=$1))
+							   WHERE id=$1;`
+
+// DeleteThread removes a thread together with its posts, revisions and votes and
+// keeps the forum and status counters and users_forum in line with what is left.
+func (r *repoPostgres) DeleteThread(ctx context.Context, thread models.Thread) error {
+	const (
+		CountLivePosts  = `SELECT count(*) FROM post WHERE thread=$1 AND NOT isdeleted;`
+		DeleteRevisions = `DELETE FROM post_revision
+						   WHERE post IN (SELECT id FROM post WHERE thread=$1);`
+		DeleteNotifications = `DELETE FROM notification
+							   WHERE post IN (SELECT id FROM post WHERE thread=$1);`
+		DeleteMentions = `DELETE FROM post_mention
+						  WHERE post IN (SELECT id FROM post WHERE thread=$1);`
+		DeleteReactions = `DELETE FROM post_reaction
+						   WHERE post IN (SELECT id FROM post WHERE thread=$1);`
+		DeletePostVotes = `DELETE FROM post_vote
+						   WHERE post IN (SELECT id FROM post WHERE thread=$1);`
+		DeleteVotes  = `DELETE FROM vote WHERE thread=$1;`
+		DeletePosts  = `DELETE FROM post WHERE thread=$1 RETURNING author;`
+		DeleteThread = `DELETE FROM thread WHERE id=$1 RETURNING author;`
+		UpdateForum  = `UPDATE forum SET threads=threads - 1, posts=posts - $2 WHERE slug=$1;`
+		UpdateStatus = `UPDATE status SET threads=threads - 1, posts=posts - $1 WHERE id=1;`
+	)
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return models.InternalError
+	}
+	defer tx.Rollback(ctx)
+
+	var livePosts int
+	err = tx.QueryRow(ctx, CountLivePosts, thread.ID).Scan(&livePosts)
+	if err != nil {
+		return models.InternalError
+	}
+	if _, err = tx.Exec(ctx, DeleteRevisions, thread.ID); err != nil {
+		return models.InternalError
+	}
+	if _, err = tx.Exec(ctx, DeleteNotifications, thread.ID); err != nil {
+		return models.InternalError
+	}
+	if _, err = tx.Exec(ctx, DeleteMentions, thread.ID); err != nil {
+		return models.InternalError
+	}
+	if _, err = tx.Exec(ctx, DeleteReactions, thread.ID); err != nil {
+		return models.InternalError
+	}
+	if _, err = tx.Exec(ctx, DeletePostVotes, thread.ID); err != nil {
+		return models.InternalError
+	}
+	if _, err = tx.Exec(ctx, DeleteVotes, thread.ID); err != nil {
+		return models.InternalError
+	}
+
+	authors := make([]string, 0)
+	rows, err := tx.Query(ctx, DeletePosts, thread.ID)
+	if err != nil {
+		return models.InternalError
+	}
+	for rows.Next() {
+		var author string
+		if err = rows.Scan(&author); err != nil {
+			rows.Close()
+			return models.InternalError
+		}
+		authors = append(authors, author)
+	}
+	rows.Close()
+
+	var threadAuthor string
+	err = tx.QueryRow(ctx, DeleteThread, thread.ID).Scan(&threadAuthor)
+	if err != nil {
+		return models.Wrap(models.NotFound, models.ResourceThread, thread.ID)
+	}
+	authors = append(authors, threadAuthor)
+
+	if _, err = tx.Exec(ctx, UpdateForum, thread.Forum, livePosts); err != nil {
+		return models.InternalError
+	}
+	if _, err = tx.Exec(ctx, UpdateStatus, livePosts); err != nil {
+		return models.InternalError
+	}
+	if _, err = tx.Exec(ctx, cleanUsersForum, thread.Forum, authors); err != nil {
+		return models.InternalError
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.InternalError
+	}
+	return nil
+}
+
+// MoveThread moves a thread with all of its posts and their notifications to
+// another forum, fixing the counters and users_forum of both forums. thread is
+// the thread as the caller checked it; the move is refused with Conflict when
+// it has changed forums since.
+func (r *repoPostgres) MoveThread(ctx context.Context, thread models.Thread, slug string) (models.Thread, error) {
+	const (
+		LockThread = `SELECT forum FROM thread WHERE id=$1 FOR UPDATE;`
+		MoveThread = `UPDATE thread SET forum=$1 WHERE id=$2
+					  RETURNING id, title, author, forum, message, votes, slug, created, isclosed, ispinned, replies, lastpostat;`
+		MovePosts         = `UPDATE post SET forum=$1 WHERE thread=$2 RETURNING author, isdeleted;`
+		MoveNotifications = `UPDATE notification SET forum=$1 WHERE thread=$2;`
+		UpdateForumCounts = `UPDATE forum SET threads=threads + $2, posts=posts + $3 WHERE slug=$1;`
+		FillUsersForum    = `INSERT INTO users_forum (nickname, fullname, about, email, slug)
+							 SELECT nickname, fullname, about, email, $1
+							 FROM users WHERE nickname = ANY ($2)
+							 ON CONFLICT DO NOTHING;`
+	)
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	defer tx.Rollback(ctx)
+
+	var forum string
+	if err = tx.QueryRow(ctx, LockThread, thread.ID).Scan(&forum); err != nil {
+		return models.Thread{}, models.Wrap(models.NotFound, models.ResourceThread, thread.ID)
+	}
+	if !strings.EqualFold(forum, thread.Forum) {
+		return models.Thread{}, models.Describe(models.Conflict, models.ResourceThread, thread.ID, "Thread was moved meanwhile")
+	}
+
+	moved := models.Thread{}
+	row := tx.QueryRow(ctx, MoveThread, slug, thread.ID)
+	err = row.Scan(&moved.ID, &moved.Title, &moved.Author, &moved.Forum,
+		&moved.Message, &moved.Votes, &moved.Slug, &moved.Created, &moved.Closed, &moved.Pinned, &moved.Replies, &moved.LastPostAt)
+	if err != nil {
+		return models.Thread{}, models.Wrap(models.NotFound, models.ResourceThread, thread.ID)
+	}
+
+	// Live posts are counted as they are moved: a post deleted meanwhile has
+	// already been taken off the forum it was in.
+	authors := []string{moved.Author}
+	livePosts := 0
+	rows, err := tx.Query(ctx, MovePosts, slug, thread.ID)
+	if err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	for rows.Next() {
+		var author string
+		var deleted bool
+		if err = rows.Scan(&author, &deleted); err != nil {
+			rows.Close()
+			return models.Thread{}, models.InternalError
+		}
+		authors = append(authors, author)
+		if !deleted {
+			livePosts++
+		}
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return models.Thread{}, models.InternalError
+	}
+
+	if _, err = tx.Exec(ctx, MoveNotifications, slug, thread.ID); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, UpdateForumCounts, thread.Forum, -1, -livePosts); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, UpdateForumCounts, slug, 1, livePosts); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, FillUsersForum, slug, authors); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, cleanUsersForum, thread.Forum, authors); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	return moved, nil
+}
+
+// lockThreads locks the threads with the given ids, in id order so that
+// concurrent merges can't deadlock, and returns them as they are now. checked
+// are the same threads as the caller authorized them; Conflict is returned
+// when one of them has changed forums since.
+func lockThreads(ctx context.Context, tx pgx.Tx, checked ...models.Thread) (map[int]models.Thread, error) {
+	const (
+		LockThreads = `SELECT id, author, forum, message, created
+					   FROM thread WHERE id = ANY ($1)
+					   ORDER BY id
+					   FOR UPDATE;`
+	)
+	ids := make([]int, 0, len(checked))
+	for _, thread := range checked {
+		ids = append(ids, thread.ID)
+	}
+	rows, err := tx.Query(ctx, LockThreads, ids)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+
+	locked := make(map[int]models.Thread, len(checked))
+	for rows.Next() {
+		thread := models.Thread{}
+		if err = rows.Scan(&thread.ID, &thread.Author, &thread.Forum, &thread.Message, &thread.Created); err != nil {
+			return nil, models.InternalError
+		}
+		locked[thread.ID] = thread
+	}
+	if rows.Err() != nil {
+		return nil, models.InternalError
+	}
+	for _, thread := range checked {
+		current, ok := locked[thread.ID]
+		if !ok {
+			return nil, models.Wrap(models.NotFound, models.ResourceThread, thread.ID)
+		}
+		if !strings.EqualFold(current.Forum, thread.Forum) {
+			return nil, models.Describe(models.Conflict, models.ResourceThread, thread.ID, "Thread was moved meanwhile")
+		}
+	}
+	return locked, nil
+}
+
+// MergeThreads moves every post of source into target. The opening message of
+// source becomes a root post of target and the former root posts of source are
+// re-parented under it, so that the materialized paths stay a valid tree. Both
+// threads are locked for the merge, and the notifications about posts of source
+// follow them to target.
+func (r *repoPostgres) MergeThreads(ctx context.Context, target models.Thread, source models.Thread) (models.Thread, error) {
+	const (
+		InsertOpener = `INSERT INTO post(author, created, forum, message, parent, thread)
+						VALUES ($1, $2, $3, $4, 0, $5) RETURNING id;`
+		MergePosts = `UPDATE post SET thread=$1, forum=$2,
+						  parent=CASE WHEN parent = 0 THEN $3 ELSE parent END,
+						  path=ARRAY[$3::INTEGER] || path
+					  WHERE thread=$4 RETURNING author, isdeleted;`
+		MergeNotifications = `UPDATE notification SET thread=$1, forum=$2 WHERE thread=$3;`
+		DeleteVotes        = `DELETE FROM vote WHERE thread=$1;`
+		DeleteThread       = `DELETE FROM thread WHERE id=$1;`
+		UpdateForumCounts  = `UPDATE forum SET threads=threads + $2, posts=posts + $3 WHERE slug=$1;`
+		UpdateStatus       = `UPDATE status SET threads=threads - 1 WHERE id=1;`
+		FillUsersForum     = `INSERT INTO users_forum (nickname, fullname, about, email, slug)
+							  SELECT nickname, fullname, about, email, $1
+							  FROM users WHERE nickname = ANY ($2)
+							  ON CONFLICT DO NOTHING;`
+	)
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	defer tx.Rollback(ctx)
+
+	locked, err := lockThreads(ctx, tx, target, source)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	target, source = locked[target.ID], locked[source.ID]
+
+	var opener int
+	row := tx.QueryRow(ctx, InsertOpener, source.Author, source.Created, target.Forum, source.Message, target.ID)
+	if err = row.Scan(&opener); err != nil {
+		return models.Thread{}, convertPgErr(err)
+	}
+
+	authors := []string{source.Author}
+	livePosts := 0
+	rows, err := tx.Query(ctx, MergePosts, target.ID, target.Forum, opener, source.ID)
+	if err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	for rows.Next() {
+		var author string
+		var deleted bool
+		if err = rows.Scan(&author, &deleted); err != nil {
+			rows.Close()
+			return models.Thread{}, models.InternalError
+		}
+		authors = append(authors, author)
+		if !deleted {
+			livePosts++
+		}
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return models.Thread{}, models.InternalError
+	}
+
+	if _, err = tx.Exec(ctx, MergeNotifications, target.ID, target.Forum, source.ID); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, refreshThreadActivity, target.ID); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, DeleteVotes, source.ID); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, DeleteThread, source.ID); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, UpdateForumCounts, source.Forum, -1, -livePosts); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, UpdateForumCounts, target.Forum, 0, livePosts); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, UpdateStatus); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, FillUsersForum, target.Forum, authors); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, cleanUsersForum, source.Forum, authors); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	return r.GetThreadByID(ctx, target.ID)
+}
+
+// SplitThread turns a post and its subtree into a new thread. The post becomes a
+// root post of the new thread and the paths of the subtree lose the ancestors
+// the post had in the old thread. post is the post as the caller checked it;
+// the old thread is locked for the split, and the notifications about the moved
+// posts follow them to the new thread.
+func (r *repoPostgres) SplitThread(ctx context.Context, post models.Post, thread models.Thread) (models.Thread, error) {
+	const (
+		SelectPostForUpdate = `SELECT author, message, created, forum, path
+							   FROM post WHERE id=$1 AND thread=$2 AND NOT isdeleted
+							   FOR UPDATE;`
+		InsertThread = `INSERT INTO thread (author, message, title, created, forum, slug, votes, lastpostat)
+						VALUES ($1, $2, $3, $4, $5, $6, 0, $4) RETURNING id;`
+		SplitPosts = `UPDATE post SET thread=$1,
+						  parent=CASE WHEN id = $2 THEN 0 ELSE parent END,
+						  path=path[array_length($3::INTEGER[], 1):]
+					  WHERE thread=$4 AND path[1:array_length($3::INTEGER[], 1)] = $3::INTEGER[];`
+		SplitNotifications = `UPDATE notification n SET thread=$1
+							  FROM post p
+							  WHERE p.id = n.post AND p.thread=$1 AND n.thread=$2;`
+	)
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = lockThreads(ctx, tx, models.Thread{ID: post.Thread, Forum: post.Forum}); err != nil {
+		return models.Thread{}, err
+	}
+	row := tx.QueryRow(ctx, SelectPostForUpdate, post.ID, post.Thread)
+	err = row.Scan(&post.Author, &post.Message, &post.Created, &post.Forum, &post.Path)
+	if err != nil {
+		return models.Thread{}, models.Describe(models.Conflict, models.ResourcePost, post.ID, "Post was moved or deleted meanwhile")
+	}
+
+	thread.Author = post.Author
+	thread.Message = post.Message
+	thread.Created = post.Created
+	thread.Forum = post.Forum
+	row = tx.QueryRow(ctx, InsertThread, thread.Author, thread.Message, thread.Title,
+		thread.Created, thread.Forum, thread.Slug)
+	if err = row.Scan(&thread.ID); err != nil {
+		return models.Thread{}, convertPgErr(err)
+	}
+
+	if _, err = tx.Exec(ctx, SplitPosts, thread.ID, post.ID, post.Path, post.Thread); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	if _, err = tx.Exec(ctx, SplitNotifications, thread.ID, post.Thread); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	for _, id := range []int{post.Thread, thread.ID} {
+		if _, err = tx.Exec(ctx, refreshThreadActivity, id); err != nil {
+			return models.Thread{}, models.InternalError
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.Thread{}, models.InternalError
+	}
+	return r.GetThreadByID(ctx, thread.ID)
+}
+
+func (r *repoPostgres) GetUsersOfForum(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.User, error) {
+	desc := params.Desc == "true"
+	q := newQuery(`SELECT nickname, fullname, about, email
+				   FROM users_forum`).and("slug = ?", forum.Slug)
+	if params.Since != "" {
+		q.after("nickname", desc, "?", params.Since)
+	}
+	q.orderBy(desc, "nickname").limit(params.Limit)
+
+	users := make([]models.User, 0)
+	rows, err := r.Conn.Query(ctx, q.String(), q.args...)
+
+	if err != nil {
+		return users, queryErr(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		user := models.User{}
+		err = rows.Scan(&user.NickName, &user.FullName, &user.About, &user.Email)
+		if err != nil {
+			return users, models.InternalError
+		}
+		users = append(users, user)
+	}
+	if rows.Err() != nil {
+		return nil, queryErr(rows.Err())
+	}
+	return users, nil
+}
+
+func (r *repoPostgres) GetFullPostInfo(ctx context.Context, posts models.PostFull, related []string) (models.PostFull, error) {
+	post := models.Post{}
+	postFull := models.PostFull{}
+
+	const (
+		SelectPostById = `SELECT author, message, created, forum, isedited, parent, thread, isdeleted, score
+						  FROM post WHERE id = $1;`
+	)
+
+	post.ID = posts.Post.ID
+
+	row := r.Conn.QueryRow(ctx, SelectPostById, posts.Post.ID)
+	err := row.Scan(&post.Author, &post.Message, &post.Created, &post.Forum, &post.IsEdited, &post.Parent, &post.Thread, &post.IsDeleted, &post.Score)
+	if err != nil {
+		return postFull, models.Wrap(models.NotFound, models.ResourcePost, posts.Post.ID)
+	}
+	tombstone(&post)
+
+	postFull.Post = post
+
+	for i := 0; i < len(related); i++ {
+		if "user" == related[i] && !post.IsDeleted {
+			user, _ := r.GetUser(ctx, post.Author)
+			postFull.Author = &user
+		}
+		if "forum" == related[i] {
 			forumS, _ := r.GetForum(ctx, post.Forum)
 			postFull.Forum = &forumS
 		}
@@ -574,19 +1317,471 @@ func (r *repoPostgres) GetFullPostInfo(ctx context.Context, posts models.PostFul
 
 func (r *repoPostgres) UpdatePostInfo(ctx context.Context, postUpdate models.PostUpdate) (models.Post, error) {
 	const (
-		UpdatePostMessage = `UPDATE post SET message=coalesce(nullif($1, ''), message), isedited = CASE WHEN $1 = '' OR message = $1 THEN isedited ELSE TRUE END WHERE id=$2 RETURNING *`
+		SelectPostForUpdate = `SELECT author, message, created
+							   FROM post WHERE id=$1 AND NOT isdeleted
+							   FOR UPDATE;`
+		UpdatePostMessage = `UPDATE post SET message = CASE WHEN $1 THEN $2 ELSE message END, isedited = isedited OR $1,
+							 messagehtml = CASE WHEN $1 THEN NULL ELSE messagehtml END
+							 WHERE id=$3 AND NOT isdeleted
+							 RETURNING id, author, created, forum, isedited, message, parent, thread, path, score`
+		InsertOriginalRevision = `INSERT INTO post_revision(post, version, message, editor, created)
+								  SELECT $1, 1, $2, $3, $4
+								  WHERE NOT EXISTS(SELECT 1 FROM post_revision WHERE post=$1);`
+		InsertRevision = `INSERT INTO post_revision(post, version, message, editor)
+						  SELECT $1, max(version) + 1, $2, $3
+						  FROM post_revision WHERE post=$1;`
 	)
 	postOne := models.Post{}
-	row := r.Conn.QueryRow(ctx, UpdatePostMessage, postUpdate.Message, postUpdate.ID)
-	err := row.Scan(&postOne.ID, &postOne.Author, &postOne.Created, &postOne.Forum,
-		&postOne.IsEdited, &postOne.Message, &postOne.Parent, &postOne.Thread, &postOne.Path)
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return postOne, models.InternalError
+	}
+	defer tx.Rollback(ctx)
+
+	original := models.Post{}
+	row := tx.QueryRow(ctx, SelectPostForUpdate, postUpdate.ID)
+	err = row.Scan(&original.Author, &original.Message, &original.Created)
+	if err != nil {
+		return postOne, models.Wrap(models.NotFound, models.ResourcePost, postUpdate.ID)
+	}
+
+	// Whether the message changes is decided once, here, so that the edited
+	// flag, the rendered message and the revisions always agree.
+	changed := postUpdate.Message != "" && postUpdate.Message != original.Message
+	row = tx.QueryRow(ctx, UpdatePostMessage, changed, postUpdate.Message, postUpdate.ID)
+	err = row.Scan(&postOne.ID, &postOne.Author, &postOne.Created, &postOne.Forum,
+		&postOne.IsEdited, &postOne.Message, &postOne.Parent, &postOne.Thread, &postOne.Path, &postOne.Score)
 	if err != nil {
 		fmt.Println(err)
-		return postOne, models.NotFound
+		return postOne, models.Wrap(models.NotFound, models.ResourcePost, postUpdate.ID)
+	}
+
+	// The first edit also stores the original text so that every version can be listed.
+	if changed {
+		_, err = tx.Exec(ctx, InsertOriginalRevision, postUpdate.ID, original.Message, original.Author, original.Created)
+		if err != nil {
+			return postOne, models.InternalError
+		}
+		_, err = tx.Exec(ctx, InsertRevision, postUpdate.ID, postUpdate.Message, postUpdate.Editor)
+		if err != nil {
+			return postOne, models.InternalError
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return postOne, models.InternalError
 	}
 	return postOne, nil
 }
 
+func (r *repoPostgres) GetPostRevisions(ctx context.Context, id int) ([]models.PostRevision, error) {
+	const (
+		SelectRevisions = `SELECT version, post, message, editor, created
+						   FROM post_revision WHERE post=$1
+						   ORDER BY version;`
+	)
+	rows, err := r.Conn.Query(ctx, SelectRevisions, id)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+
+	revisions := make([]models.PostRevision, 0)
+	for rows.Next() {
+		revision := models.PostRevision{}
+		err = rows.Scan(&revision.Version, &revision.Post, &revision.Message, &revision.Editor, &revision.Created)
+		if err != nil {
+			return nil, models.InternalError
+		}
+		revisions = append(revisions, revision)
+	}
+	if rows.Err() != nil {
+		return nil, models.InternalError
+	}
+	return revisions, nil
+}
+
+func (r *repoPostgres) SetPostDeleted(ctx context.Context, id int, deleted bool) (models.Post, error) {
+	const (
+		UpdatePostDeleted = `UPDATE post SET isdeleted=$1
+							 WHERE id=$2 AND isdeleted <> $1
+							 RETURNING id, author, created, forum, isedited, message, parent, thread, isdeleted, score`
+	)
+	post := models.Post{}
+	row := r.Conn.QueryRow(ctx, UpdatePostDeleted, deleted, id)
+	err := row.Scan(&post.ID, &post.Author, &post.Created, &post.Forum,
+		&post.IsEdited, &post.Message, &post.Parent, &post.Thread, &post.IsDeleted, &post.Score)
+	if err != nil {
+		return post, models.Wrap(models.NotFound, models.ResourcePost, id)
+	}
+	tombstone(&post)
+	return post, nil
+}
+
+// Markers ts_headline puts around the matched words of a snippet. They are
+// private use characters, removed from the text beforehand, so they can't be
+// forged and survive HTML escaping unchanged.
+const (
+	headlineStart = "\uE000"
+	headlineStop  = "\uE001"
+)
+
+var headlineTags = strings.NewReplacer(headlineStart, "<b>", headlineStop, "</b>")
+
+// highlight escapes a headline and turns its markers into <b> tags, so the
+// snippet is safe to render whatever markup the text contained.
+func highlight(headline string) string {
+	return headlineTags.Replace(html.EscapeString(headline))
+}
+
+// Search looks for posts and threads matching the query, best ranked first.
+// Pagination is keyset based on (rank, type, id) of the last returned hit.
+// Snippets are HTML with the matched words in <b> tags.
+func (r *repoPostgres) Search(ctx context.Context, params models.SearchParams) ([]models.SearchHit, error) {
+	const (
+		SearchPostsAndThreads = `SELECT kind, id, thread, forum, author, title,
+								 ts_headline('english', translate(body, $10, ''), plainto_tsquery('english', $1), $9),
+								 rank, created
+								 FROM (SELECT * FROM (
+									 SELECT 'post' AS kind, p.id, p.thread, p.forum, p.author, t.title,
+									 p.message::TEXT AS body, p.created,
+									 ts_rank(to_tsvector('english', p.message::TEXT), q) AS rank
+									 FROM post p JOIN thread t ON t.id = p.thread, plainto_tsquery('english', $1) q
+									 WHERE to_tsvector('english', p.message::TEXT) @@ q AND NOT p.isdeleted
+									 AND (nullif($2, '') IS NULL OR p.forum = $2::CITEXT)
+									 AND (nullif($3, '') IS NULL OR p.author = $3::CITEXT)
+									 UNION ALL
+									 SELECT 'thread', t.id, t.id, t.forum, t.author, t.title,
+									 t.title || ' ' || t.message, t.created,
+									 ts_rank(to_tsvector('english', t.title || ' ' || t.message), q)
+									 FROM thread t, plainto_tsquery('english', $1) q
+									 WHERE to_tsvector('english', t.title || ' ' || t.message) @@ q
+									 AND (nullif($2, '') IS NULL OR t.forum = $2::CITEXT)
+									 AND (nullif($3, '') IS NULL OR t.author = $3::CITEXT)
+								 ) matches
+								 WHERE NOT $4::BOOLEAN OR (rank, kind, id) < ($5::REAL, $6::TEXT, $7::INTEGER)
+								 ORDER BY rank DESC, kind DESC, id DESC
+								 LIMIT $8) hits
+								 ORDER BY rank DESC, kind DESC, id DESC;`
+	)
+	after := models.SearchHit{}
+	if params.After != nil {
+		after = *params.After
+	}
+	hits := make([]models.SearchHit, 0)
+	rows, err := r.Conn.Query(ctx, SearchPostsAndThreads, params.Query, params.Forum, params.Author,
+		params.After != nil, after.Rank, after.Type, after.ID, params.Limit,
+		"StartSel="+headlineStart+", StopSel="+headlineStop+", MaxFragments=2, MaxWords=30, MinWords=10",
+		headlineStart+headlineStop)
+	if err != nil {
+		return hits, models.InternalError
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		hit := models.SearchHit{}
+		err = rows.Scan(&hit.Type, &hit.ID, &hit.Thread, &hit.Forum, &hit.Author, &hit.Title,
+			&hit.Snippet, &hit.Rank, &hit.Created)
+		if err != nil {
+			return hits, models.InternalError
+		}
+		hit.Snippet = highlight(hit.Snippet)
+		hits = append(hits, hit)
+	}
+	if rows.Err() != nil {
+		return nil, models.InternalError
+	}
+	return hits, nil
+}
+
+// ToggleReaction retracts the user's reaction when it is the same emoji and sets
+// it otherwise. A retracted reaction keeps its row with the time it was
+// retracted. It reports whether the user has a reaction afterwards.
+func (r *repoPostgres) ToggleReaction(ctx context.Context, reaction models.Reaction) (bool, error) {
+	const (
+		RetractReaction = `UPDATE post_reaction SET retracted=now()
+						   WHERE post=$1 AND nickname=$2 AND emoji=$3 AND retracted IS NULL;`
+		UpsertReaction = `INSERT INTO post_reaction (post, nickname, emoji)
+						  VALUES ($1, $2, $3)
+						  ON CONFLICT (post, nickname) DO UPDATE SET emoji=excluded.emoji, created=now(), retracted=NULL;`
+	)
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return false, models.InternalError
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, RetractReaction, reaction.Post, reaction.Nickname, reaction.Emoji)
+	if err != nil {
+		return false, models.InternalError
+	}
+	reacted := tag.RowsAffected() == 0
+	if reacted {
+		_, err = tx.Exec(ctx, UpsertReaction, reaction.Post, reaction.Nickname, reaction.Emoji)
+		if err != nil {
+			return false, convertPgErr(err)
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return false, models.InternalError
+	}
+	return reacted, nil
+}
+
+func (r *repoPostgres) GetReactions(ctx context.Context, id int) ([]models.Reaction, error) {
+	const (
+		SelectReactions = `SELECT post, nickname, emoji, created
+						   FROM post_reaction WHERE post=$1 AND retracted IS NULL
+						   ORDER BY created, nickname;`
+	)
+	rows, err := r.Conn.Query(ctx, SelectReactions, id)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+
+	reactions := make([]models.Reaction, 0)
+	for rows.Next() {
+		reaction := models.Reaction{}
+		err = rows.Scan(&reaction.Post, &reaction.Nickname, &reaction.Emoji, &reaction.Created)
+		if err != nil {
+			return nil, models.InternalError
+		}
+		reactions = append(reactions, reaction)
+	}
+	if rows.Err() != nil {
+		return nil, models.InternalError
+	}
+	return reactions, nil
+}
+
+// GetReactionCounts returns per post the number of users per emoji.
+func (r *repoPostgres) GetReactionCounts(ctx context.Context, ids []int) (map[int]map[string]int, error) {
+	const (
+		SelectCounts = `SELECT post, emoji, count(*)
+						FROM post_reaction WHERE post = ANY ($1) AND retracted IS NULL
+						GROUP BY post, emoji;`
+	)
+	rows, err := r.Conn.Query(ctx, SelectCounts, ids)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+
+	counts := make(map[int]map[string]int)
+	for rows.Next() {
+		var id, count int
+		var emoji string
+		if err = rows.Scan(&id, &emoji, &count); err != nil {
+			return nil, models.InternalError
+		}
+		if counts[id] == nil {
+			counts[id] = make(map[string]int)
+		}
+		counts[id][emoji] = count
+	}
+	return counts, nil
+}
+
+// GetPostsHTML returns the cached rendered messages of the posts that have one.
+func (r *repoPostgres) GetPostsHTML(ctx context.Context, ids []int) (map[int]string, error) {
+	const (
+		SelectPostsHTML = `SELECT id, messagehtml FROM post
+						   WHERE id = ANY ($1) AND messagehtml IS NOT NULL;`
+	)
+	rows, err := r.Conn.Query(ctx, SelectPostsHTML, ids)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+
+	rendered := make(map[int]string, len(ids))
+	for rows.Next() {
+		var id int
+		var html string
+		if err = rows.Scan(&id, &html); err != nil {
+			return nil, models.InternalError
+		}
+		rendered[id] = html
+	}
+	return rendered, nil
+}
+
+// SetPostsHTML caches rendered messages. UpdatePostInfo drops the cache of a
+// post whose message changes.
+func (r *repoPostgres) SetPostsHTML(ctx context.Context, rendered map[int]string) error {
+	const (
+		UpdatePostsHTML = `UPDATE post SET messagehtml = r.html
+						   FROM unnest($1::INT[], $2::TEXT[]) AS r(id, html)
+						   WHERE post.id = r.id;`
+	)
+	ids := make([]int, 0, len(rendered))
+	htmls := make([]string, 0, len(rendered))
+	for id, html := range rendered {
+		ids = append(ids, id)
+		htmls = append(htmls, html)
+	}
+	_, err := r.Conn.Exec(ctx, UpdatePostsHTML, ids, htmls)
+	if err != nil {
+		return models.InternalError
+	}
+	return nil
+}
+
+// SetPostMentions replaces the mentions of every post in the map. Nicknames
+// that don't belong to a user are dropped.
+func (r *repoPostgres) SetPostMentions(ctx context.Context, mentions map[int][]string) error {
+	const (
+		DeleteMentions = `DELETE FROM post_mention WHERE post = ANY ($1);`
+		InsertMentions = `INSERT INTO post_mention (post, nickname)
+						  SELECT m.post, u.nickname
+						  FROM unnest($1::INT[], $2::TEXT[]) AS m(post, nickname)
+						  JOIN users u ON u.nickname = m.nickname::CITEXT
+						  ON CONFLICT DO NOTHING;`
+	)
+	ids := make([]int, 0, len(mentions))
+	postIDs := make([]int, 0)
+	nicknames := make([]string, 0)
+	for id, mentioned := range mentions {
+		ids = append(ids, id)
+		for _, nickname := range mentioned {
+			postIDs = append(postIDs, id)
+			nicknames = append(nicknames, nickname)
+		}
+	}
+
+	tx, err := r.Conn.Begin(ctx)
+	if err != nil {
+		return models.InternalError
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, DeleteMentions, ids); err != nil {
+		return models.InternalError
+	}
+	if len(nicknames) > 0 {
+		if _, err = tx.Exec(ctx, InsertMentions, postIDs, nicknames); err != nil {
+			return models.InternalError
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return models.InternalError
+	}
+	return nil
+}
+
+// GetMentions returns the live posts mentioning the user, newest first.
+func (r *repoPostgres) GetMentions(ctx context.Context, nickname string, params models.SortParams) ([]models.Post, error) {
+	q := newQuery(`SELECT p.id, p.author, p.created, p.forum, p.isedited, p.message, p.parent, p.thread, p.score
+				   FROM post_mention m JOIN post p ON p.id = m.post`).and("m.nickname = ?", nickname).and("NOT p.isdeleted")
+	if since, _ := strconv.Atoi(params.Since); since > 0 {
+		q.after("p.id", true, "?", since)
+	}
+	q.orderBy(true, "p.id").limit(params.Limit)
+
+	rows, err := r.Conn.Query(ctx, q.String(), q.args...)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+
+	posts := make([]models.Post, 0)
+	for rows.Next() {
+		post := models.Post{}
+		err = rows.Scan(&post.ID, &post.Author, &post.Created, &post.Forum, &post.IsEdited, &post.Message,
+			&post.Parent, &post.Thread, &post.Score)
+		if err != nil {
+			return nil, models.InternalError
+		}
+		posts = append(posts, post)
+	}
+	if rows.Err() != nil {
+		return nil, models.InternalError
+	}
+	return posts, nil
+}
+
+// CreateNotifications notifies the users the posts mention. Reply and thread
+// notifications are inserted along with the posts, so a reply that also
+// mentions the parent's author shows up as a reply; users already notified
+// about a post are not notified again.
+func (r *repoPostgres) CreateNotifications(ctx context.Context, posts []models.Post) error {
+	const (
+		InsertMentions = `INSERT INTO notification (nickname, kind, post, thread, forum, author, created)
+						  SELECT m.nickname, 'mention', p.id, p.thread, p.forum, p.author, p.created
+						  FROM post_mention m JOIN post p ON p.id = m.post
+						  WHERE p.id = ANY ($1) AND m.nickname <> p.author
+						  ON CONFLICT DO NOTHING;`
+	)
+	ids := make([]int, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+
+	if _, err := r.Conn.Exec(ctx, InsertMentions, ids); err != nil {
+		return models.InternalError
+	}
+	return nil
+}
+
+func (r *repoPostgres) GetNotifications(ctx context.Context, nickname string, params models.NotificationParams) ([]models.Notification, error) {
+	q := newQuery(`SELECT id, nickname, kind, post, thread, forum, author, isread, created
+				   FROM notification`).and("nickname = ?", nickname)
+	if params.Since > 0 {
+		q.after("id", true, "?", params.Since)
+	}
+	if params.Unread {
+		q.and("NOT isread")
+	}
+	q.orderBy(true, "id").limit(strconv.Itoa(params.Limit))
+
+	rows, err := r.Conn.Query(ctx, q.String(), q.args...)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+
+	notifications := make([]models.Notification, 0)
+	for rows.Next() {
+		n := models.Notification{}
+		err = rows.Scan(&n.ID, &n.Nickname, &n.Kind, &n.Post, &n.Thread, &n.Forum, &n.Author, &n.Read, &n.Created)
+		if err != nil {
+			return nil, models.InternalError
+		}
+		notifications = append(notifications, n)
+	}
+	if rows.Err() != nil {
+		return nil, models.InternalError
+	}
+	return notifications, nil
+}
+
+func (r *repoPostgres) MarkNotificationRead(ctx context.Context, nickname string, id int) (models.Notification, error) {
+	const (
+		UpdateNotification = `UPDATE notification SET isread=true
+							  WHERE id=$1 AND nickname=$2
+							  RETURNING id, nickname, kind, post, thread, forum, author, isread, created;`
+	)
+	n := models.Notification{}
+	err := r.Conn.QueryRow(ctx, UpdateNotification, id, nickname).
+		Scan(&n.ID, &n.Nickname, &n.Kind, &n.Post, &n.Thread, &n.Forum, &n.Author, &n.Read, &n.Created)
+	if err != nil {
+		return models.Notification{}, models.Wrap(models.NotFound, models.ResourceNotification, id)
+	}
+	return n, nil
+}
+
+func (r *repoPostgres) MarkAllNotificationsRead(ctx context.Context, nickname string) error {
+	const (
+		UpdateNotifications = `UPDATE notification SET isread=true WHERE nickname=$1 AND NOT isread;`
+	)
+	_, err := r.Conn.Exec(ctx, UpdateNotifications, nickname)
+	if err != nil {
+		return models.InternalError
+	}
+	return nil
+}
+
 // TODO maybe should do 1 query
 func (r *repoPostgres) GetStatus(ctx context.Context) models.Status {
 	const (
@@ -601,7 +1796,7 @@ func (r *repoPostgres) GetStatus(ctx context.Context) models.Status {
 
 func (r *repoPostgres) GetClear(ctx context.Context) {
 	const (
-		ClearAll = `TRUNCATE TABLE users, forum, thread, post, vote, users_forum, status CASCADE;`
+		ClearAll = `TRUNCATE TABLE users, forum, forum_moderator, thread, post, post_revision, vote, users_forum, post_vote, post_reaction, post_mention, notification, webhook, webhook_delivery, status CASCADE;`
 	)
 	_, _ = r.Conn.Exec(ctx, ClearAll)
 }
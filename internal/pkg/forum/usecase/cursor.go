@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// page is a list request after its cursor, if any, has been applied. Backward
+// pages are read in the opposite order and flipped back by the caller, so the
+// repository only ever pages forwards from since.
+type page struct {
+	sort  string
+	desc  bool
+	back  bool
+	limit int
+	since bool
+}
+
+// newPage applies the cursor of params, which must have been issued for one of
+// sorts; the first of sorts is the listing's default.
+func newPage(params *models.SortParams, sorts ...string) (page, error) {
+	if params.Cursor != "" {
+		cursor, err := utils.DecodeCursor(params.Cursor)
+		if err != nil || !contains(sorts, cursor.Sort) || !validCursor(cursor) {
+			return page{}, models.Describe(models.BadRequest, "", "", "Invalid cursor")
+		}
+		params.Sort = cursor.Sort
+		params.Since = cursor.Since
+		params.Key = cursor.Key
+		params.Desc = strconv.FormatBool(cursor.Desc != cursor.Back)
+		p := page{sort: cursor.Sort, desc: cursor.Desc, back: cursor.Back, since: true}
+		p.limit, _ = strconv.Atoi(params.Limit)
+		return p, nil
+	}
+
+	p := page{sort: params.Sort, desc: params.Desc == "true", since: params.Since != ""}
+	if !contains(sorts, p.sort) {
+		p.sort = sorts[0]
+	}
+	p.limit, _ = strconv.Atoi(params.Limit)
+	return p, nil
+}
+
+// validCursor checks the since and key of a decoded cursor have the type the
+// repository compares them as, INTEGER, TIMESTAMPTZ or INTEGER[] depending on
+// the sort, so a forged cursor is refused before it reaches the query.
+func validCursor(cursor models.Cursor) bool {
+	if cursor.Sort == "nickname" {
+		return cursor.Key == cursor.Since
+	}
+	if _, err := strconv.ParseInt(cursor.Since, 10, 32); err != nil {
+		return false
+	}
+	switch cursor.Sort {
+	case "created", "activity":
+		_, err := time.Parse(time.RFC3339Nano, cursor.Key)
+		return err == nil
+	case "tree", "score":
+		if !strings.HasPrefix(cursor.Key, "{") || !strings.HasSuffix(cursor.Key, "}") {
+			return false
+		}
+		for _, n := range strings.Split(strings.Trim(cursor.Key, "{}"), ",") {
+			if _, err := strconv.ParseInt(n, 10, 32); err != nil {
+				return false
+			}
+		}
+		return true
+	default:
+		_, err := strconv.ParseInt(cursor.Key, 10, 32)
+		return err == nil
+	}
+}
+
+// cursors builds the cursors around a page whose boundary rows are first and
+// last, given by their since and key. count is what the page counted against
+// its limit; a full page may have more rows behind it.
+func (p page) cursors(count int, first models.Cursor, last models.Cursor) models.Cursors {
+	cursors := models.Cursors{}
+	if count == 0 {
+		return cursors
+	}
+	full := p.limit > 0 && count >= p.limit
+	last.Sort, last.Desc = p.sort, p.desc
+	first.Sort, first.Desc, first.Back = p.sort, p.desc, true
+	next := utils.EncodeCursor(last)
+	prev := utils.EncodeCursor(first)
+	if p.back {
+		cursors.Next = next
+		if full {
+			cursors.Prev = prev
+		}
+		return cursors
+	}
+	if full {
+		cursors.Next = next
+	}
+	if p.since {
+		cursors.Prev = prev
+	}
+	return cursors
+}
+
+// postBound is where a page of posts starts or ends: flat and parent_tree pages
+// are keyed by id, tree and score pages by the position the repository read.
+func postBound(sort string, post models.Post) models.Cursor {
+	id := strconv.Itoa(post.ID)
+	if sort == "tree" || sort == "score" {
+		return models.Cursor{Since: id, Key: post.Key}
+	}
+	return models.Cursor{Since: id, Key: id}
+}
+
+// threadBound is where a page of threads starts or ends: the value of the sort
+// column, with the id breaking ties.
+func threadBound(sort string, thread models.Thread) models.Cursor {
+	bound := models.Cursor{Since: strconv.Itoa(thread.ID)}
+	switch sort {
+	case "votes":
+		bound.Key = strconv.Itoa(thread.Votes)
+	case "replies":
+		bound.Key = strconv.Itoa(thread.Replies)
+	case "activity":
+		bound.Key = thread.LastPostAt.Format(time.RFC3339Nano)
+	default:
+		bound.Key = thread.Created.Format(time.RFC3339Nano)
+	}
+	return bound
+}
+
+func reversePosts(posts []models.Post) []models.Post {
+	for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+		posts[i], posts[j] = posts[j], posts[i]
+	}
+	return posts
+}
+
+// reverseTrees reverses the order of the root posts of a parent_tree page while
+// keeping every root followed by its own replies.
+func reverseTrees(posts []models.Post) []models.Post {
+	reversed := make([]models.Post, 0, len(posts))
+	end := len(posts)
+	for i := len(posts) - 1; i >= 0; i-- {
+		if posts[i].Parent == 0 || i == 0 {
+			reversed = append(reversed, posts[i:end]...)
+			end = i
+		}
+	}
+	return reversed
+}
+
+func reverseThreads(threads []models.Thread) []models.Thread {
+	for i, j := 0, len(threads)-1; i < j; i, j = i+1, j-1 {
+		threads[i], threads[j] = threads[j], threads[i]
+	}
+	return threads
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
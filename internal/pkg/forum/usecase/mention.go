@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"context"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/utils"
+	"strconv"
+)
+
+const (
+	defaultMentionsLimit = 20
+	maxMentionsLimit     = 100
+)
+
+// setMentions stores the @nickname mentions parsed from the posts' messages,
+// replacing whatever the posts mentioned before.
+func (u *UseCase) setMentions(ctx context.Context, posts []models.Post) error {
+	mentions := make(map[int][]string, len(posts))
+	for _, post := range posts {
+		mentions[post.ID] = utils.ParseMentions(post.Message)
+	}
+	return u.repo.SetPostMentions(ctx, mentions)
+}
+
+func (u *UseCase) GetMentions(ctx context.Context, nickname string, params models.SortParams) ([]models.Post, error) {
+	user, err := u.repo.GetUser(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+	limit, _ := strconv.Atoi(params.Limit)
+	if limit <= 0 {
+		limit = defaultMentionsLimit
+	}
+	if limit > maxMentionsLimit {
+		limit = maxMentionsLimit
+	}
+	params.Limit = strconv.Itoa(limit)
+	return u.repo.GetMentions(ctx, user.NickName, params)
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"log"
+)
+
+const (
+	defaultNotificationsLimit = 20
+	maxNotificationsLimit     = 100
+)
+
+// notify records the mentions of freshly created or edited posts and the
+// mention notifications they cause; reply and thread notifications are stored
+// with the posts. The posts are already stored, so a failure here is logged and
+// doesn't fail the request.
+func (u *UseCase) notify(ctx context.Context, posts []models.Post) {
+	if err := u.setMentions(ctx, posts); err != nil {
+		log.Print("can't store mentions: ", err)
+	}
+	if err := u.repo.CreateNotifications(ctx, posts); err != nil {
+		log.Print("can't create notifications: ", err)
+	}
+}
+
+func (u *UseCase) GetNotifications(ctx context.Context, nickname string, params models.NotificationParams) ([]models.Notification, error) {
+	user, err := u.notificationsOwner(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultNotificationsLimit
+	}
+	if params.Limit > maxNotificationsLimit {
+		params.Limit = maxNotificationsLimit
+	}
+	return u.repo.GetNotifications(ctx, user.NickName, params)
+}
+
+func (u *UseCase) MarkNotificationRead(ctx context.Context, nickname string, id int) (models.Notification, error) {
+	user, err := u.notificationsOwner(ctx, nickname)
+	if err != nil {
+		return models.Notification{}, err
+	}
+	return u.repo.MarkNotificationRead(ctx, user.NickName, id)
+}
+
+func (u *UseCase) MarkAllNotificationsRead(ctx context.Context, nickname string) error {
+	user, err := u.notificationsOwner(ctx, nickname)
+	if err != nil {
+		return err
+	}
+	return u.repo.MarkAllNotificationsRead(ctx, user.NickName)
+}
+
+// notificationsOwner resolves the user whose inbox is accessed and checks the
+// caller may read it.
+func (u *UseCase) notificationsOwner(ctx context.Context, nickname string) (models.User, error) {
+	reader, err := caller(ctx)
+	if err != nil {
+		return models.User{}, err
+	}
+	user, err := u.repo.GetUser(ctx, nickname)
+	if err != nil {
+		return models.User{}, err
+	}
+	if !u.canReadNotifications(ctx, reader, user.NickName) {
+		return models.User{}, models.Forbidden
+	}
+	return user, nil
+}
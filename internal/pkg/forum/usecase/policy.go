@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"context"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"strings"
+)
+
+// The rules below are the only place where the use case decides who may act on
+// whose data. Admins may do anything; forum owners and moderators may moderate
+// content inside their forum; everyone else may only touch their own content.
+
+func (u *UseCase) isAdmin(ctx context.Context, nickname string) bool {
+	user, err := u.repo.GetUser(ctx, nickname)
+	return err == nil && user.Role == models.RoleAdmin
+}
+
+func (u *UseCase) canModerate(ctx context.Context, nickname string, slug string) bool {
+	if u.isAdmin(ctx, nickname) {
+		return true
+	}
+	forum, err := u.repo.GetForum(ctx, slug)
+	if err == nil && strings.EqualFold(forum.User, nickname) {
+		return true
+	}
+	moderator, err := u.repo.IsForumModerator(ctx, slug, nickname)
+	return err == nil && moderator
+}
+
+func (u *UseCase) canEditUser(ctx context.Context, nickname string, user models.User) bool {
+	return strings.EqualFold(nickname, user.NickName) || u.isAdmin(ctx, nickname)
+}
+
+func (u *UseCase) canReadNotifications(ctx context.Context, nickname string, owner string) bool {
+	return strings.EqualFold(nickname, owner) || u.isAdmin(ctx, nickname)
+}
+
+func (u *UseCase) canEditPost(ctx context.Context, nickname string, post models.Post) bool {
+	return strings.EqualFold(nickname, post.Author) || u.canModerate(ctx, nickname, post.Forum)
+}
+
+func (u *UseCase) canEditThread(ctx context.Context, nickname string, thread models.Thread) bool {
+	return strings.EqualFold(nickname, thread.Author) || u.canModerate(ctx, nickname, thread.Forum)
+}
+
+func (u *UseCase) canDeleteThread(ctx context.Context, nickname string, thread models.Thread) bool {
+	return u.canModerate(ctx, nickname, thread.Forum)
+}
+
+func (u *UseCase) canManageForum(ctx context.Context, nickname string, forum models.Forum) bool {
+	return strings.EqualFold(nickname, forum.User) || u.isAdmin(ctx, nickname)
+}
+
+func (u *UseCase) canManageModerators(ctx context.Context, nickname string, forum models.Forum) bool {
+	return u.canManageForum(ctx, nickname, forum)
+}
+
+func (u *UseCase) canRestorePost(ctx context.Context, nickname string) bool {
+	return u.isAdmin(ctx, nickname)
+}
+
+func (u *UseCase) canClear(ctx context.Context, nickname string) bool {
+	return u.isAdmin(ctx, nickname)
+}
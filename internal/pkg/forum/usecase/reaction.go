@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"context"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+)
+
+// ToggleReaction reacts to the post with emoji, or takes the reaction back when
+// the caller already reacted with the same emoji.
+func (u *UseCase) ToggleReaction(ctx context.Context, id int, emoji string) (models.Post, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Post{}, err
+	}
+	if !u.reactions[emoji] {
+		return models.Post{}, models.Describe(models.BadRequest, models.ResourcePost, id, "This emoji is not allowed")
+	}
+	post, err := u.repo.GetFullPostInfo(ctx, models.PostFull{Post: models.Post{ID: id}}, nil)
+	if err != nil {
+		return models.Post{}, err
+	}
+	if post.Post.IsDeleted {
+		return models.Post{}, models.Wrap(models.NotFound, models.ResourcePost, id)
+	}
+	_, err = u.repo.ToggleReaction(ctx, models.Reaction{Post: id, Nickname: nickname, Emoji: emoji})
+	if err != nil {
+		return models.Post{}, err
+	}
+	return u.withReactions(ctx, []models.Post{post.Post})[0], nil
+}
+
+func (u *UseCase) GetReactions(ctx context.Context, id int) ([]models.Reaction, error) {
+	post, err := u.repo.GetFullPostInfo(ctx, models.PostFull{Post: models.Post{ID: id}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if post.Post.IsDeleted {
+		return nil, models.Wrap(models.NotFound, models.ResourcePost, id)
+	}
+	return u.repo.GetReactions(ctx, id)
+}
+
+// withReactions fills the reaction counts of the posts. Counts are decoration,
+// so the posts are returned as they are when they can't be loaded.
+func (u *UseCase) withReactions(ctx context.Context, posts []models.Post) []models.Post {
+	if len(posts) == 0 {
+		return posts
+	}
+	ids := make([]int, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+	counts, err := u.repo.GetReactionCounts(ctx, ids)
+	if err != nil {
+		return posts
+	}
+	for i := range posts {
+		posts[i].Reactions = counts[posts[i].ID]
+	}
+	return posts
+}
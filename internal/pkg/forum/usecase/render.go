@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"context"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/utils"
+)
+
+// RenderPosts fills MessageHTML of the posts, rendering and caching the ones
+// that have not been rendered yet. Deleted posts are rendered from their
+// tombstone and never touch the cache, which holds the real message.
+func (u *UseCase) RenderPosts(ctx context.Context, posts []models.Post) []models.Post {
+	ids := make([]int, 0, len(posts))
+	for _, post := range posts {
+		if !post.IsDeleted {
+			ids = append(ids, post.ID)
+		}
+	}
+	cached := map[int]string{}
+	if len(ids) > 0 {
+		cached, _ = u.repo.GetPostsHTML(ctx, ids)
+	}
+
+	rendered := make(map[int]string)
+	for i := range posts {
+		if posts[i].IsDeleted {
+			posts[i].MessageHTML = utils.RenderMarkdown(posts[i].Message)
+			continue
+		}
+		html, ok := cached[posts[i].ID]
+		if !ok {
+			html = utils.RenderMarkdown(posts[i].Message)
+			rendered[posts[i].ID] = html
+		}
+		posts[i].MessageHTML = html
+	}
+	if len(rendered) > 0 {
+		_ = u.repo.SetPostsHTML(ctx, rendered)
+	}
+	return posts
+}
+
+func (u *UseCase) RenderThread(thread models.Thread) models.Thread {
+	thread.MessageHTML = utils.RenderMarkdown(thread.Message)
+	return thread
+}
@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+func (u *UseCase) Search(ctx context.Context, query string, forum string, author string, limit int, cursor string) (models.SearchResult, error) {
+	result := models.SearchResult{Hits: []models.SearchHit{}}
+	if strings.TrimSpace(query) == "" {
+		return result, nil
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	params := models.SearchParams{Query: query, Forum: forum, Author: author, Limit: limit + 1}
+	if cursor != "" {
+		after, err := decodeSearchCursor(cursor)
+		if err != nil {
+			return result, err
+		}
+		params.After = &after
+	}
+
+	hits, err := u.repo.Search(ctx, params)
+	if err != nil {
+		return result, err
+	}
+	if len(hits) > limit {
+		hits = hits[:limit]
+		result.NextCursor = encodeSearchCursor(hits[limit-1])
+	}
+	result.Hits = hits
+	return result, nil
+}
+
+var errMalformedSearchCursor = models.Describe(models.BadRequest, "", "", "Invalid cursor")
+
+// The search cursor is the (rank, type, id) key of the last hit of a page.
+func encodeSearchCursor(hit models.SearchHit) string {
+	key := strconv.FormatFloat(float64(hit.Rank), 'g', -1, 32) + "|" + hit.Type + "|" + strconv.Itoa(hit.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+func decodeSearchCursor(cursor string) (models.SearchHit, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return models.SearchHit{}, errMalformedSearchCursor
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return models.SearchHit{}, errMalformedSearchCursor
+	}
+	rank, err := strconv.ParseFloat(parts[0], 32)
+	if err != nil {
+		return models.SearchHit{}, errMalformedSearchCursor
+	}
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return models.SearchHit{}, errMalformedSearchCursor
+	}
+	return models.SearchHit{Rank: float32(rank), Type: parts[1], ID: id}, nil
+}
@@ -2,17 +2,48 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/auth"
 	"github.com/DESOLATE17/Database-term-project/internal/pkg/forum"
+	"github.com/DESOLATE17/Database-term-project/internal/utils"
+	"github.com/mailru/easyjson"
+	"golang.org/x/crypto/bcrypt"
 	"strconv"
+	"strings"
 )
 
 type UseCase struct {
-	repo forum.Repository
+	repo      forum.Repository
+	events    forum.Publisher
+	reactions map[string]bool
 }
 
-func NewRepoUsecase(repo forum.Repository) forum.UseCase {
-	return &UseCase{repo: repo}
+// NewRepoUsecase builds the forum use case. reactions is the set of emoji
+// users may react to posts with.
+func NewRepoUsecase(repo forum.Repository, events forum.Publisher, reactions []string) forum.UseCase {
+	allowed := make(map[string]bool, len(reactions))
+	for _, emoji := range reactions {
+		allowed[emoji] = true
+	}
+	return &UseCase{repo: repo, events: events, reactions: allowed}
+}
+
+// caller returns the nickname of the authenticated user making the request.
+func caller(ctx context.Context) (string, error) {
+	nickname, ok := auth.Caller(ctx)
+	if !ok {
+		return "", models.Unauthorized
+	}
+	return nickname, nil
+}
+
+func (u *UseCase) publish(eventType string, id int, forum string, thread int, author string, payload easyjson.Marshaler) {
+	data, err := easyjson.Marshal(payload)
+	if err != nil {
+		return
+	}
+	u.events.Publish(models.Event{ID: id, Type: eventType, Forum: forum, Thread: thread, Author: author, Data: data})
 }
 
 func (u *UseCase) GetUser(ctx context.Context, user models.User) (models.User, error) {
@@ -22,36 +53,144 @@ func (u *UseCase) GetUser(ctx context.Context, user models.User) (models.User, e
 func (u *UseCase) CreateUser(ctx context.Context, user models.User) ([]models.User, error) {
 	usersWithSameInfo, _ := u.repo.CheckUserEmailAndNicknameUniq(ctx, user)
 	if len(usersWithSameInfo) > 0 {
-		return usersWithSameInfo, models.Conflict
+		return usersWithSameInfo, models.WithDetails(models.Conflict, models.ResourceUser, user.NickName, usersWithSameInfo)
+	}
+	if user.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, models.InternalError
+		}
+		user.Password = string(hash)
 	}
 	err := u.repo.CreateUser(ctx, user)
 	if err != nil {
 		return nil, err
 	}
+	user.Password = ""
+	user.Role = models.RoleUser
 	return []models.User{user}, nil
 }
 
 func (u *UseCase) UpdateUserInfo(ctx context.Context, user models.User) (models.User, error) {
-	return u.repo.UpdateUserInfo(ctx, user)
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.User{}, err
+	}
+	if !u.canEditUser(ctx, nickname, user) {
+		return models.User{}, models.Forbidden
+	}
+	updated, err := u.repo.UpdateUserInfo(ctx, user)
+	return updated, models.Wrap(err, models.ResourceUser, user.NickName)
 }
 
 func (u *UseCase) CreateForum(ctx context.Context, forum models.Forum) (models.Forum, error) {
-	user, err := u.repo.GetUser(ctx, forum.User)
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Forum{}, err
+	}
+	user, err := u.repo.GetUser(ctx, nickname)
 	if err != nil {
 		return models.Forum{}, err
 	}
 	forum.User = user.NickName
 
+	if forum.Parent != "" {
+		forum.Parent, err = u.repo.ForumCheck(ctx, forum.Parent)
+		if err != nil {
+			return models.Forum{}, err
+		}
+	}
+
 	err = u.repo.CreateForum(ctx, forum)
-	if err == models.Conflict {
+	if errors.Is(err, models.Conflict) {
 		forum, _ = u.repo.GetForum(ctx, forum.Slug)
-		return forum, models.Conflict
+		return forum, models.WithDetails(models.Conflict, models.ResourceForum, forum.Slug, forum)
+	}
+	if err != nil {
+		// The parent may have been deleted since it was checked.
+		return models.Forum{}, models.Wrap(err, models.ResourceForum, forum.Parent)
 	}
 	return forum, nil
 }
 
 func (u *UseCase) ForumInfo(ctx context.Context, slug string) (models.Forum, error) {
-	return u.repo.GetForum(ctx, slug)
+	forum, err := u.repo.GetForum(ctx, slug)
+	if err != nil {
+		return forum, err
+	}
+	forum.TotalPosts, forum.TotalThreads, err = u.repo.GetForumTotals(ctx, forum.Slug)
+	return forum, err
+}
+
+// GetForumTree returns the root forums with their subforums nested inside and
+// totals rolled up from the leaves.
+func (u *UseCase) GetForumTree(ctx context.Context) ([]models.ForumNode, error) {
+	forums, err := u.repo.GetForums(ctx)
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[string][]models.Forum)
+	for _, forum := range forums {
+		parent := strings.ToLower(forum.Parent)
+		children[parent] = append(children[parent], forum)
+	}
+
+	var build func(forum models.Forum) models.ForumNode
+	build = func(forum models.Forum) models.ForumNode {
+		node := models.ForumNode{Forum: forum}
+		node.TotalPosts, node.TotalThreads = forum.Posts, forum.Threads
+		for _, child := range children[strings.ToLower(forum.Slug)] {
+			childNode := build(child)
+			node.TotalPosts += childNode.TotalPosts
+			node.TotalThreads += childNode.TotalThreads
+			node.Children = append(node.Children, childNode)
+		}
+		return node
+	}
+
+	roots := make([]models.ForumNode, 0)
+	for _, forum := range children[""] {
+		roots = append(roots, build(forum))
+	}
+	return roots, nil
+}
+
+func (u *UseCase) UpdateForum(ctx context.Context, forum models.Forum) (models.Forum, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Forum{}, err
+	}
+	current, err := u.repo.GetForum(ctx, forum.Slug)
+	if err != nil {
+		return models.Forum{}, err
+	}
+	if !u.canManageForum(ctx, nickname, current) {
+		return models.Forum{}, models.Forbidden
+	}
+	if forum.User != "" {
+		owner, err := u.repo.GetUser(ctx, forum.User)
+		if err != nil {
+			return models.Forum{}, err
+		}
+		forum.User = owner.NickName
+	}
+	forum.Slug = current.Slug
+	return u.repo.UpdateForum(ctx, forum)
+}
+
+func (u *UseCase) DeleteForum(ctx context.Context, slug string) error {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+	forum, err := u.repo.GetForum(ctx, slug)
+	if err != nil {
+		return err
+	}
+	if !u.canManageForum(ctx, nickname, forum) {
+		return models.Forbidden
+	}
+	return u.repo.DeleteForum(ctx, forum.Slug)
 }
 
 func (u *UseCase) CheckThreadIdOrSlug(ctx context.Context, slugOrId string) (models.Thread, error) {
@@ -63,55 +202,237 @@ func (u *UseCase) CheckThreadIdOrSlug(ctx context.Context, slugOrId string) (mod
 }
 
 func (u *UseCase) CreatePosts(ctx context.Context, posts []models.Post, thread models.Thread) ([]models.Post, error) {
-	return u.repo.CreatePosts(ctx, posts, thread)
+	nickname, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if thread.Closed {
+		return nil, models.Describe(models.ThreadClosed, models.ResourceThread, thread.ID, "Thread is closed for new posts")
+	}
+	for i := range posts {
+		posts[i].Author = nickname
+	}
+	posts, err = u.repo.CreatePosts(ctx, posts, thread)
+	if err != nil {
+		return posts, err
+	}
+	u.notify(ctx, posts)
+	for _, post := range posts {
+		u.publish(models.EventPostCreated, post.ID, post.Forum, post.Thread, post.Author, post)
+	}
+	return posts, nil
 }
 
 func (u *UseCase) CreateForumThread(ctx context.Context, thread models.Thread) (models.Thread, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	thread.Author = nickname
+
 	if thread.Slug != "" {
 		th, err := u.repo.GetThreadBySlug(ctx, thread.Slug)
 		if err == nil {
-			return th, models.Conflict
+			return th, models.WithDetails(models.Conflict, models.ResourceThread, th.Slug, th)
 		}
 	}
-	f, status := u.repo.ForumCheck(ctx, thread.Forum)
-	if status == models.NotFound {
-		return models.Thread{}, models.NotFound
+	f, err := u.repo.ForumCheck(ctx, thread.Forum)
+	if err != nil {
+		return models.Thread{}, err
 	}
 	thread.Forum = f
 
-	return u.repo.CreateThread(ctx, thread)
+	thread, err = u.repo.CreateThread(ctx, thread)
+	if err != nil {
+		return thread, err
+	}
+	u.publish(models.EventThreadCreated, thread.ID, thread.Forum, thread.ID, thread.Author, thread)
+	return thread, nil
 }
 
-func (u *UseCase) GetPostOfThread(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, error) {
-	switch params.Sort {
-	case "flat":
-		return u.repo.GetPostsFlat(ctx, params, threadID)
+func (u *UseCase) GetPostOfThread(ctx context.Context, params models.SortParams, threadID int) ([]models.Post, models.Cursors, error) {
+	pg, err := newPage(&params, "flat", "tree", "parent_tree", "score")
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	var posts []models.Post
+	switch pg.sort {
 	case "tree":
-		return u.repo.GetPostsTree(ctx, params, threadID)
+		posts, err = u.repo.GetPostsTree(ctx, params, threadID)
 	case "parent_tree":
-		return u.repo.GetPostsParent(ctx, params, threadID)
+		posts, err = u.repo.GetPostsParent(ctx, params, threadID)
+	case "score":
+		posts, err = u.repo.GetPostsScore(ctx, params, threadID)
+	default:
+		posts, err = u.repo.GetPostsFlat(ctx, params, threadID)
+	}
+	if err != nil {
+		return posts, models.Cursors{}, err
+	}
+	if len(posts) == 0 {
+		return posts, models.Cursors{}, nil
+	}
+
+	// parent_tree pages count and continue from root posts; the others from posts.
+	if pg.sort == "parent_tree" {
+		var roots []models.Post
+		for _, post := range posts {
+			if post.Parent == 0 {
+				roots = append(roots, post)
+			}
+		}
+		if pg.back {
+			posts = reverseTrees(posts)
+			roots = reversePosts(roots)
+		}
+		if len(roots) == 0 {
+			return u.withReactions(ctx, posts), models.Cursors{}, nil
+		}
+		cursors := pg.cursors(len(roots), postBound(pg.sort, roots[0]), postBound(pg.sort, roots[len(roots)-1]))
+		return u.withReactions(ctx, posts), cursors, nil
+	}
+	if pg.back {
+		posts = reversePosts(posts)
+	}
+	cursors := pg.cursors(len(posts), postBound(pg.sort, posts[0]), postBound(pg.sort, posts[len(posts)-1]))
+	return u.withReactions(ctx, posts), cursors, nil
+}
+
+func (u *UseCase) GetForumThreads(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.Thread, models.Cursors, error) {
+	pg, err := newPage(&params, "created", "votes", "activity", "replies")
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	_, err = u.repo.GetForum(ctx, forum.Slug)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	threads, err := u.repo.GetForumThreads(ctx, forum, params)
+	if err != nil {
+		return threads, models.Cursors{}, err
+	}
+	if pg.back {
+		threads = reverseThreads(threads)
+	}
+	cursors := models.Cursors{}
+	if len(threads) > 0 {
+		cursors = pg.cursors(len(threads), threadBound(pg.sort, threads[0]), threadBound(pg.sort, threads[len(threads)-1]))
+	}
+
+	// Pinned threads head the page that starts a listing: any request without a
+	// cursor, with or without since, and a backward page that reaches the start.
+	// They are read on their own and never count towards the limit, so a page
+	// holds limit regular threads and its cursors always point at one of them.
+	if params.Cursor != "" && (!pg.back || cursors.Prev != "") {
+		return threads, cursors, nil
+	}
+	params.Desc = strconv.FormatBool(pg.desc)
+	pinned, err := u.repo.GetPinnedThreads(ctx, forum, params)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	return append(pinned, threads...), cursors, nil
+}
+
+// Vote sets the caller's voice on a thread; a voice of 0 retracts the vote.
+func (u *UseCase) Vote(ctx context.Context, vote models.Vote) error {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+	vote.Nickname = nickname
+
+	switch vote.Voice {
+	case 0:
+		return u.RetractVote(ctx, vote)
+	case 1, -1:
 	default:
-		return u.repo.GetPostsFlat(ctx, params, threadID)
+		return models.Describe(models.BadRequest, models.ResourceVote, vote.Voice, "Voice must be 1, -1 or 0 to retract")
+	}
+
+	err = u.repo.Vote(ctx, vote)
+	if errors.Is(err, models.Conflict) {
+		err = u.repo.UpdateVote(ctx, vote)
+	}
+	if err != nil {
+		return models.Wrap(err, models.ResourceThread, vote.Thread)
 	}
+	u.publishVote(ctx, vote)
+	return nil
 }
 
-func (u *UseCase) GetForumThreads(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.Thread, error) {
-	_, err := u.repo.GetForum(ctx, forum.Slug)
+func (u *UseCase) RetractVote(ctx context.Context, vote models.Vote) error {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+	vote.Nickname = nickname
+
+	if err = u.repo.RetractVote(ctx, vote); err != nil {
+		return models.Wrap(err, models.ResourceVote, vote.Thread)
+	}
+	u.publishVote(ctx, vote)
+	return nil
+}
+
+func (u *UseCase) publishVote(ctx context.Context, vote models.Vote) {
+	if thread, err := u.repo.GetThreadByID(ctx, vote.Thread); err == nil {
+		u.publish(models.EventVoteChanged, thread.ID, thread.Forum, thread.ID, vote.Nickname, thread)
+	}
+}
+
+// GetVotes lists who voted on a thread and when. Only moderators of the
+// thread's forum may see it.
+func (u *UseCase) GetVotes(ctx context.Context, slugOrId string) ([]models.Vote, error) {
+	nickname, err := caller(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return u.repo.GetForumThreads(ctx, forum, params)
+	thread, err := u.CheckThreadIdOrSlug(ctx, slugOrId)
+	if err != nil {
+		return nil, err
+	}
+	if !u.canModerate(ctx, nickname, thread.Forum) {
+		return nil, models.Forbidden
+	}
+	return u.repo.GetVotes(ctx, thread.ID)
 }
 
-func (u *UseCase) Vote(ctx context.Context, vote models.Vote) error {
-	err := u.repo.Vote(ctx, vote)
-	if err == models.Conflict {
-		return u.repo.UpdateVote(ctx, vote)
+// VotePost sets the caller's +1/-1 voice on a post and returns the post with
+// its new score.
+func (u *UseCase) VotePost(ctx context.Context, vote models.Vote) (models.Post, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Post{}, err
+	}
+	if vote.Voice != 1 && vote.Voice != -1 {
+		return models.Post{}, models.Describe(models.BadRequest, models.ResourceVote, vote.Voice, "Voice must be 1 or -1")
+	}
+	vote.Nickname = nickname
+	if err = u.repo.VotePost(ctx, vote); err != nil {
+		return models.Post{}, err
+	}
+	post, err := u.GetFullPostInfo(ctx, models.PostFull{Post: models.Post{ID: vote.Post}}, nil)
+	if err != nil {
+		return models.Post{}, err
 	}
-	return err
+	u.publish(models.EventVoteChanged, post.Post.ID, post.Post.Forum, post.Post.Thread, nickname, post.Post)
+	return post.Post, nil
 }
 
 func (u *UseCase) UpdateThreadInfo(ctx context.Context, slugOrId string, updateThread models.Thread) (models.Thread, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	thread, err := u.CheckThreadIdOrSlug(ctx, slugOrId)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	if !u.canEditThread(ctx, nickname, thread) {
+		return models.Thread{}, models.Forbidden
+	}
+
 	threadID, err := strconv.Atoi(slugOrId)
 	if err != nil {
 		updateThread.Slug = slugOrId
@@ -121,27 +442,337 @@ func (u *UseCase) UpdateThreadInfo(ctx context.Context, slugOrId string, updateT
 	return u.repo.UpdateThreadInfo(ctx, updateThread)
 }
 
-func (u *UseCase) GetUsersOfForum(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.User, error) {
-	_, err := u.repo.GetForum(ctx, forum.Slug)
+func (u *UseCase) CloseThread(ctx context.Context, slugOrId string, closed bool) (models.Thread, error) {
+	nickname, err := caller(ctx)
 	if err != nil {
-		return nil, err
+		return models.Thread{}, err
 	}
+	thread, err := u.CheckThreadIdOrSlug(ctx, slugOrId)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	if !u.canEditThread(ctx, nickname, thread) {
+		return models.Thread{}, models.Forbidden
+	}
+	return u.repo.SetThreadClosed(ctx, thread.ID, closed)
+}
 
-	return u.repo.GetUsersOfForum(ctx, forum, params)
+func (u *UseCase) PinThread(ctx context.Context, slugOrId string, pinned bool) (models.Thread, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	thread, err := u.CheckThreadIdOrSlug(ctx, slugOrId)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	if !u.canModerate(ctx, nickname, thread.Forum) {
+		return models.Thread{}, models.Forbidden
+	}
+	return u.repo.SetThreadPinned(ctx, thread.ID, pinned)
+}
+
+func (u *UseCase) MoveThread(ctx context.Context, slugOrId string, slug string) (models.Thread, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	thread, err := u.CheckThreadIdOrSlug(ctx, slugOrId)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	target, err := u.repo.ForumCheck(ctx, slug)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	if !u.canModerate(ctx, nickname, thread.Forum) || !u.canModerate(ctx, nickname, target) {
+		return models.Thread{}, models.Forbidden
+	}
+	if target == thread.Forum {
+		return thread, nil
+	}
+	return u.repo.MoveThread(ctx, thread, target)
+}
+
+func (u *UseCase) MergeThreads(ctx context.Context, targetSlugOrId string, sourceSlugOrId string) (models.Thread, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	target, err := u.CheckThreadIdOrSlug(ctx, targetSlugOrId)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	source, err := u.CheckThreadIdOrSlug(ctx, sourceSlugOrId)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	if target.ID == source.ID {
+		return models.Thread{}, models.Describe(models.Conflict, models.ResourceThread, target.ID, "Can't merge a thread into itself")
+	}
+	if !u.canModerate(ctx, nickname, target.Forum) || !u.canModerate(ctx, nickname, source.Forum) {
+		return models.Thread{}, models.Forbidden
+	}
+	return u.repo.MergeThreads(ctx, target, source)
+}
+
+func (u *UseCase) SplitThread(ctx context.Context, postID int, thread models.Thread) (models.Thread, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	post, err := u.repo.GetFullPostInfo(ctx, models.PostFull{Post: models.Post{ID: postID}}, nil)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	if post.Post.IsDeleted {
+		return models.Thread{}, models.Wrap(models.NotFound, models.ResourcePost, postID)
+	}
+	if !u.canModerate(ctx, nickname, post.Post.Forum) {
+		return models.Thread{}, models.Forbidden
+	}
+	if thread.Slug != "" {
+		th, err := u.repo.GetThreadBySlug(ctx, thread.Slug)
+		if err == nil {
+			return th, models.WithDetails(models.Conflict, models.ResourceThread, th.Slug, th)
+		}
+	}
+	return u.repo.SplitThread(ctx, post.Post, thread)
+}
+
+func (u *UseCase) DeleteThread(ctx context.Context, slugOrId string) error {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+	thread, err := u.CheckThreadIdOrSlug(ctx, slugOrId)
+	if err != nil {
+		return err
+	}
+	if !u.canDeleteThread(ctx, nickname, thread) {
+		return models.Forbidden
+	}
+	return u.repo.DeleteThread(ctx, thread)
+}
+
+func (u *UseCase) GetUsersOfForum(ctx context.Context, forum models.Forum, params models.SortParams) ([]models.User, models.Cursors, error) {
+	pg, err := newPage(&params, "nickname")
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	_, err = u.repo.GetForum(ctx, forum.Slug)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+
+	users, err := u.repo.GetUsersOfForum(ctx, forum, params)
+	if err != nil || len(users) == 0 {
+		return users, models.Cursors{}, err
+	}
+	if pg.back {
+		for i, j := 0, len(users)-1; i < j; i, j = i+1, j-1 {
+			users[i], users[j] = users[j], users[i]
+		}
+	}
+	first := models.Cursor{Since: users[0].NickName, Key: users[0].NickName}
+	last := models.Cursor{Since: users[len(users)-1].NickName, Key: users[len(users)-1].NickName}
+	return users, pg.cursors(len(users), first, last), nil
 }
 
 func (u *UseCase) GetFullPostInfo(ctx context.Context, posts models.PostFull, related []string) (models.PostFull, error) {
-	return u.repo.GetFullPostInfo(ctx, posts, related)
+	postFull, err := u.repo.GetFullPostInfo(ctx, posts, related)
+	if err != nil {
+		return postFull, err
+	}
+	postFull.Post = u.withReactions(ctx, []models.Post{postFull.Post})[0]
+	return postFull, nil
 }
 
 func (u *UseCase) UpdatePostInfo(ctx context.Context, postUpdate models.PostUpdate) (models.Post, error) {
-	return u.repo.UpdatePostInfo(ctx, postUpdate)
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Post{}, err
+	}
+	post, err := u.repo.GetFullPostInfo(ctx, models.PostFull{Post: models.Post{ID: postUpdate.ID}}, nil)
+	if err != nil {
+		return models.Post{}, err
+	}
+	if !u.canEditPost(ctx, nickname, post.Post) {
+		return models.Post{}, models.Forbidden
+	}
+	postUpdate.Editor = nickname
+	updated, err := u.repo.UpdatePostInfo(ctx, postUpdate)
+	if err != nil {
+		return updated, err
+	}
+	u.notify(ctx, []models.Post{updated})
+	u.publish(models.EventPostEdited, updated.ID, updated.Forum, updated.Thread, nickname, updated)
+	return updated, nil
 }
 
-func (u *UseCase) GetClear(ctx context.Context) {
+func (u *UseCase) GetPostRevisions(ctx context.Context, id int) ([]models.PostRevision, error) {
+	post, err := u.repo.GetFullPostInfo(ctx, models.PostFull{Post: models.Post{ID: id}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if post.Post.IsDeleted {
+		nickname, ok := auth.Caller(ctx)
+		if !ok || !u.canModerate(ctx, nickname, post.Post.Forum) {
+			return nil, models.Wrap(models.NotFound, models.ResourcePost, id)
+		}
+	}
+
+	revisions, err := u.repo.GetPostRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Posts that were never edited have no stored revisions, the post itself is the only version.
+	if len(revisions) == 0 {
+		revisions = append(revisions, models.PostRevision{
+			Version: 1,
+			Post:    id,
+			Message: post.Post.Message,
+			Editor:  post.Post.Author,
+			Created: post.Post.Created,
+		})
+	}
+	return revisions, nil
+}
+
+func (u *UseCase) GetPostDiff(ctx context.Context, id int, from int, to int, mode string) (models.PostDiff, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.PostDiff{}, err
+	}
+	post, err := u.repo.GetFullPostInfo(ctx, models.PostFull{Post: models.Post{ID: id}}, nil)
+	if err != nil {
+		return models.PostDiff{}, err
+	}
+	if !u.canEditPost(ctx, nickname, post.Post) {
+		return models.PostDiff{}, models.Forbidden
+	}
+
+	revisions, err := u.GetPostRevisions(ctx, id)
+	if err != nil {
+		return models.PostDiff{}, err
+	}
+	if to == 0 {
+		to = len(revisions)
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	if from < 1 {
+		from = 1
+	}
+	if from > len(revisions) || to > len(revisions) || to < 1 {
+		return models.PostDiff{}, models.Wrap(models.NotFound, models.ResourceRevision, strconv.Itoa(from)+".."+strconv.Itoa(to))
+	}
+	if mode != models.DiffModeWord {
+		mode = models.DiffModeLine
+	}
+
+	return models.PostDiff{
+		Post:    id,
+		From:    from,
+		To:      to,
+		Mode:    mode,
+		Changes: utils.Diff(revisions[from-1].Message, revisions[to-1].Message, mode),
+	}, nil
+}
+
+func (u *UseCase) DeletePost(ctx context.Context, id int) (models.Post, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Post{}, err
+	}
+	post, err := u.repo.GetFullPostInfo(ctx, models.PostFull{Post: models.Post{ID: id}}, nil)
+	if err != nil {
+		return models.Post{}, err
+	}
+	if post.Post.IsDeleted {
+		return models.Post{}, models.Wrap(models.NotFound, models.ResourcePost, id)
+	}
+	if !u.canEditPost(ctx, nickname, post.Post) {
+		return models.Post{}, models.Forbidden
+	}
+	return u.repo.SetPostDeleted(ctx, id, true)
+}
+
+func (u *UseCase) RestorePost(ctx context.Context, id int) (models.Post, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Post{}, err
+	}
+	if !u.canRestorePost(ctx, nickname) {
+		return models.Post{}, models.Forbidden
+	}
+	return u.repo.SetPostDeleted(ctx, id, false)
+}
+
+func (u *UseCase) GetClear(ctx context.Context) error {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return err
+	}
+	if !u.canClear(ctx, nickname) {
+		return models.Forbidden
+	}
 	u.repo.GetClear(ctx)
+	return nil
 }
 
 func (u *UseCase) GetStatus(ctx context.Context) models.Status {
 	return u.repo.GetStatus(ctx)
 }
+
+func (u *UseCase) GetForumModerators(ctx context.Context, slug string) ([]models.User, error) {
+	forum, err := u.repo.GetForum(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.GetForumModerators(ctx, forum.Slug)
+}
+
+func (u *UseCase) AddForumModerator(ctx context.Context, slug string, nickname string) ([]models.User, error) {
+	forum, err := u.authorizeModeratorChange(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	user, err := u.repo.GetUser(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+	err = u.repo.AddForumModerator(ctx, forum.Slug, user.NickName)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.GetForumModerators(ctx, forum.Slug)
+}
+
+func (u *UseCase) RemoveForumModerator(ctx context.Context, slug string, nickname string) ([]models.User, error) {
+	forum, err := u.authorizeModeratorChange(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	err = u.repo.RemoveForumModerator(ctx, forum.Slug, nickname)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.GetForumModerators(ctx, forum.Slug)
+}
+
+func (u *UseCase) authorizeModeratorChange(ctx context.Context, slug string) (models.Forum, error) {
+	nickname, err := caller(ctx)
+	if err != nil {
+		return models.Forum{}, err
+	}
+	forum, err := u.repo.GetForum(ctx, slug)
+	if err != nil {
+		return models.Forum{}, err
+	}
+	if !u.canManageModerators(ctx, nickname, forum) {
+		return models.Forum{}, models.Forbidden
+	}
+	return forum, nil
+}
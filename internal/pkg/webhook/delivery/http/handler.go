@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/webhook"
+	"github.com/DESOLATE17/Database-term-project/internal/utils"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+type Handler struct {
+	uc webhook.UseCase
+}
+
+func NewWebhookHandler(WebhookUseCase webhook.UseCase) *Handler {
+	return &Handler{uc: WebhookUseCase}
+}
+
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slug, found := vars["slug"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	hook := models.Webhook{}
+	if utils.Invalid(w, utils.Decode(r, &hook)) {
+		return
+	}
+	hook.Forum = slug
+	if utils.Invalid(w, hook.Validate()) {
+		return
+	}
+
+	hook, err := h.uc.CreateWebhook(r.Context(), hook)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusCreated, hook)
+}
+
+func (h *Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slug, found := vars["slug"]
+	if !found {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	hooks, err := h.uc.GetWebhooks(r.Context(), slug)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, hooks)
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slug := vars["slug"]
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.Error(w, models.NotFound)
+		return
+	}
+
+	err = h.uc.DeleteWebhook(r.Context(), slug, id)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, nil)
+}
+
+func (h *Handler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	slug := vars["slug"]
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.Error(w, models.NotFound)
+		return
+	}
+	v := models.Validator{}
+	v.Int("limit", r.URL.Query().Get("limit"), 1, models.MaxLimit)
+	if utils.Invalid(w, v.Err()) {
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	deliveries, err := h.uc.GetDeliveries(r.Context(), slug, id, limit)
+	if err != nil {
+		utils.Error(w, err)
+		return
+	}
+	utils.Response(w, http.StatusOK, deliveries)
+}
@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"context"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"time"
+)
+
+type UseCase interface {
+	CreateWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, slug string) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, slug string, id int) error
+	GetDeliveries(ctx context.Context, slug string, id int, limit int) ([]models.WebhookDelivery, error)
+	Publish(event models.Event)
+}
+
+type Repository interface {
+	GetForumOwner(ctx context.Context, slug string) (string, string, error)
+	GetUserRole(ctx context.Context, nickname string) (string, error)
+	CreateWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, slug string) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, slug string, id int) error
+	GetDeliveries(ctx context.Context, slug string, id int, limit int) ([]models.WebhookDelivery, error)
+	EnqueueDeliveries(ctx context.Context, event models.Event, payload []byte) error
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	SaveAttempt(ctx context.Context, delivery models.WebhookDelivery) error
+}
@@ -0,0 +1,196 @@
+package repo
+
+import (
+	"context"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/webhook"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type repoPostgres struct {
+	Conn *pgxpool.Pool
+}
+
+func NewRepoPostgres(Conn *pgxpool.Pool) webhook.Repository {
+	return &repoPostgres{Conn: Conn}
+}
+
+// GetForumOwner returns the canonical slug of the forum and the nickname of its owner.
+func (r *repoPostgres) GetForumOwner(ctx context.Context, slug string) (string, string, error) {
+	const (
+		SelectForumOwner = `SELECT slug, "user" FROM forum WHERE slug=$1 LIMIT 1;`
+	)
+	var owner string
+	err := r.Conn.QueryRow(ctx, SelectForumOwner, slug).Scan(&slug, &owner)
+	if err != nil {
+		return "", "", models.Wrap(models.NotFound, models.ResourceForum, slug)
+	}
+	return slug, owner, nil
+}
+
+func (r *repoPostgres) GetUserRole(ctx context.Context, nickname string) (string, error) {
+	const (
+		SelectRole = `SELECT role FROM users WHERE nickname=$1 LIMIT 1;`
+	)
+	var role string
+	err := r.Conn.QueryRow(ctx, SelectRole, nickname).Scan(&role)
+	if err != nil {
+		return "", models.Wrap(models.NotFound, models.ResourceUser, nickname)
+	}
+	return role, nil
+}
+
+func (r *repoPostgres) CreateWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error) {
+	const (
+		InsertWebhook = `INSERT INTO webhook (forum, url, secret, events)
+						 VALUES ($1, $2, $3, $4)
+						 RETURNING id, created;`
+	)
+	err := r.Conn.QueryRow(ctx, InsertWebhook, hook.Forum, hook.URL, hook.Secret, hook.Events).
+		Scan(&hook.ID, &hook.Created)
+	if err != nil {
+		return models.Webhook{}, models.InternalError
+	}
+	return hook, nil
+}
+
+func (r *repoPostgres) GetWebhooks(ctx context.Context, slug string) ([]models.Webhook, error) {
+	const (
+		SelectWebhooks = `SELECT id, forum, url, events, created
+						  FROM webhook WHERE forum=$1
+						  ORDER BY id;`
+	)
+	rows, err := r.Conn.Query(ctx, SelectWebhooks, slug)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+
+	hooks := make([]models.Webhook, 0)
+	for rows.Next() {
+		hook := models.Webhook{}
+		err = rows.Scan(&hook.ID, &hook.Forum, &hook.URL, &hook.Events, &hook.Created)
+		if err != nil {
+			return nil, models.InternalError
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
+
+func (r *repoPostgres) DeleteWebhook(ctx context.Context, slug string, id int) error {
+	const (
+		DeleteWebhook = `DELETE FROM webhook WHERE id=$1 AND forum=$2;`
+	)
+	tag, err := r.Conn.Exec(ctx, DeleteWebhook, id, slug)
+	if err != nil {
+		return models.InternalError
+	}
+	if tag.RowsAffected() == 0 {
+		return models.Wrap(models.NotFound, models.ResourceWebhook, id)
+	}
+	return nil
+}
+
+// GetDeliveries returns the most recent deliveries of a webhook, newest first.
+func (r *repoPostgres) GetDeliveries(ctx context.Context, slug string, id int, limit int) ([]models.WebhookDelivery, error) {
+	const (
+		SelectWebhook    = `SELECT id FROM webhook WHERE id=$1 AND forum=$2;`
+		SelectDeliveries = `SELECT id, webhook, event, payload, status, attempts, responsecode, lasterror,
+								   nextattempt, created, delivered
+							FROM webhook_delivery WHERE webhook=$1
+							ORDER BY id DESC
+							LIMIT $2;`
+	)
+	err := r.Conn.QueryRow(ctx, SelectWebhook, id, slug).Scan(&id)
+	if err != nil {
+		return nil, models.Wrap(models.NotFound, models.ResourceWebhook, id)
+	}
+
+	rows, err := r.Conn.Query(ctx, SelectDeliveries, id, limit)
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0)
+	for rows.Next() {
+		delivery := models.WebhookDelivery{}
+		var payload string
+		err = rows.Scan(&delivery.ID, &delivery.Webhook, &delivery.Event, &payload, &delivery.Status,
+			&delivery.Attempts, &delivery.ResponseCode, &delivery.LastError, &delivery.NextAttempt,
+			&delivery.Created, &delivery.Delivered)
+		if err != nil {
+			return nil, models.InternalError
+		}
+		delivery.Payload = []byte(payload)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+// EnqueueDeliveries queues the event for every webhook of its forum subscribed to its type.
+func (r *repoPostgres) EnqueueDeliveries(ctx context.Context, event models.Event, payload []byte) error {
+	const (
+		InsertDeliveries = `INSERT INTO webhook_delivery (webhook, event, payload)
+							SELECT id, $2, $3 FROM webhook
+							WHERE forum=$1 AND (cardinality(events) = 0 OR $2 = ANY(events));`
+	)
+	_, err := r.Conn.Exec(ctx, InsertDeliveries, event.Forum, event.Type, string(payload))
+	if err != nil {
+		return models.InternalError
+	}
+	return nil
+}
+
+// ClaimDeliveries leases up to limit due deliveries. Leased rows are pushed
+// forward by lease, so a crashed worker's deliveries are retried later and
+// concurrent workers never send the same delivery twice.
+func (r *repoPostgres) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	const (
+		ClaimDeliveries = `UPDATE webhook_delivery d
+						   SET nextattempt = now() + $2 * interval '1 millisecond'
+						   FROM webhook w
+						   WHERE w.id = d.webhook AND d.id IN (
+							   SELECT id FROM webhook_delivery
+							   WHERE status = 'pending' AND nextattempt <= now()
+							   ORDER BY nextattempt
+							   LIMIT $1
+							   FOR UPDATE SKIP LOCKED)
+						   RETURNING d.id, d.webhook, d.event, d.payload, d.status, d.attempts, w.url, w.secret;`
+	)
+	rows, err := r.Conn.Query(ctx, ClaimDeliveries, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, models.InternalError
+	}
+	defer rows.Close()
+
+	deliveries := make([]models.WebhookDelivery, 0, limit)
+	for rows.Next() {
+		delivery := models.WebhookDelivery{}
+		var payload string
+		err = rows.Scan(&delivery.ID, &delivery.Webhook, &delivery.Event, &payload, &delivery.Status,
+			&delivery.Attempts, &delivery.URL, &delivery.Secret)
+		if err != nil {
+			return nil, models.InternalError
+		}
+		delivery.Payload = []byte(payload)
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+func (r *repoPostgres) SaveAttempt(ctx context.Context, delivery models.WebhookDelivery) error {
+	const (
+		UpdateDelivery = `UPDATE webhook_delivery
+						  SET status=$2, attempts=$3, responsecode=$4, lasterror=$5, nextattempt=$6, delivered=$7
+						  WHERE id=$1;`
+	)
+	_, err := r.Conn.Exec(ctx, UpdateDelivery, delivery.ID, delivery.Status, delivery.Attempts,
+		delivery.ResponseCode, delivery.LastError, delivery.NextAttempt, delivery.Delivered)
+	if err != nil {
+		return models.InternalError
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/webhook"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// deliveryLease outlasts a batch whose every delivery times out, so no claimed
+// delivery becomes due again while it is still being sent.
+const (
+	dispatchInterval = time.Second
+	dispatchBatch    = 20
+	deliveryTimeout  = 10 * time.Second
+	deliveryLease    = dispatchBatch*deliveryTimeout + time.Minute
+	maxAttempts      = 8
+	baseBackoff      = 10 * time.Second
+)
+
+// Dispatcher sends queued deliveries and reschedules the failed ones with an
+// exponential backoff until maxAttempts is reached.
+type Dispatcher struct {
+	repo   webhook.Repository
+	client *http.Client
+}
+
+func NewDispatcher(repo webhook.Repository, client *http.Client) *Dispatcher {
+	return &Dispatcher{repo: repo, client: client}
+}
+
+// Run polls the queue until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for d.dispatch(ctx) == dispatchBatch {
+		}
+	}
+}
+
+// dispatch sends one batch and returns how many deliveries it claimed.
+func (d *Dispatcher) dispatch(ctx context.Context) int {
+	deliveries, err := d.repo.ClaimDeliveries(ctx, dispatchBatch, deliveryLease)
+	if err != nil {
+		log.Print("webhook: can't claim deliveries: ", err)
+		return 0
+	}
+	for _, delivery := range deliveries {
+		d.attempt(ctx, delivery)
+	}
+	return len(deliveries)
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery models.WebhookDelivery) {
+	code, err := d.Send(ctx, delivery)
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseCode = code
+	delivery.LastError = ""
+	delivery.NextAttempt = now
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliveryDelivered
+		delivery.Delivered = &now
+	case delivery.Attempts >= maxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = err.Error()
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttempt = now.Add(baseBackoff << (delivery.Attempts - 1))
+	}
+	if err = d.repo.SaveAttempt(ctx, delivery); err != nil {
+		log.Print("webhook: can't save delivery ", delivery.ID, ": ", err)
+	}
+}
+
+// Send posts the signed payload to the webhook URL. Any 2xx answer counts as
+// delivered, whatever the client, an attempt is cut after deliveryTimeout.
+func (d *Dispatcher) Send(ctx context.Context, delivery models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.EventHeader, delivery.Event)
+	req.Header.Set(webhook.DeliveryHeader, strconv.Itoa(delivery.ID))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(delivery.Secret, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
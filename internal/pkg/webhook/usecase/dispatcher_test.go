@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/webhook"
+	"github.com/mailru/easyjson"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// queueRepo hands out the queued deliveries once and records the saved attempts.
+type queueRepo struct {
+	webhook.Repository
+	queue []models.WebhookDelivery
+	saved []models.WebhookDelivery
+}
+
+func (r *queueRepo) ClaimDeliveries(_ context.Context, limit int, _ time.Duration) ([]models.WebhookDelivery, error) {
+	if limit > len(r.queue) {
+		limit = len(r.queue)
+	}
+	claimed := r.queue[:limit]
+	r.queue = r.queue[limit:]
+	return claimed, nil
+}
+
+func (r *queueRepo) SaveAttempt(_ context.Context, delivery models.WebhookDelivery) error {
+	r.saved = append(r.saved, delivery)
+	return nil
+}
+
+// newDelivery queues a post-created event the way Publish does.
+func newDelivery(url string, attempts int) models.WebhookDelivery {
+	event := models.Event{ID: 11, Type: models.EventPostCreated, Forum: "go", Thread: 5, Author: "gopher",
+		Data: easyjson.RawMessage(`{"id":11,"message":"hi"}`)}
+	payload, _ := easyjson.Marshal(event)
+	return models.WebhookDelivery{
+		ID:       7,
+		Webhook:  3,
+		Event:    event.Type,
+		Payload:  payload,
+		Status:   models.DeliveryPending,
+		Attempts: attempts,
+		URL:      url,
+		Secret:   "s3cret",
+	}
+}
+
+func TestSendDeliversSignedPayload(t *testing.T) {
+	delivery := newDelivery("", 0)
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != string(delivery.Payload) {
+			t.Errorf("payload = %s, want %s", body, delivery.Payload)
+		}
+		if !webhook.Verify(delivery.Secret, body, r.Header.Get(webhook.SignatureHeader)) {
+			t.Errorf("signature %q does not verify", r.Header.Get(webhook.SignatureHeader))
+		}
+		if got := r.Header.Get(webhook.EventHeader); got != delivery.Event {
+			t.Errorf("event header = %q, want %q", got, delivery.Event)
+		}
+		if got := r.Header.Get(webhook.DeliveryHeader); got != strconv.Itoa(delivery.ID) {
+			t.Errorf("delivery header = %q, want %d", got, delivery.ID)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	delivery.URL = server.URL
+
+	repo := &queueRepo{queue: []models.WebhookDelivery{delivery}}
+	if claimed := NewDispatcher(repo, server.Client()).dispatch(context.Background()); claimed != 1 {
+		t.Fatalf("claimed %d deliveries, want 1", claimed)
+	}
+	if received != 1 {
+		t.Fatalf("server got %d requests, want 1", received)
+	}
+	saved := repo.saved[0]
+	if saved.Status != models.DeliveryDelivered || saved.Delivered == nil {
+		t.Errorf("status = %s, delivered = %v, want delivered", saved.Status, saved.Delivered)
+	}
+	if saved.Attempts != 1 || saved.ResponseCode != http.StatusNoContent || saved.LastError != "" {
+		t.Errorf("attempt = %+v", saved)
+	}
+}
+
+func TestSendRetriesServerErrorsWithBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	repo := &queueRepo{queue: []models.WebhookDelivery{
+		newDelivery(server.URL, 0),
+		newDelivery(server.URL, 2),
+		newDelivery(server.URL, maxAttempts-1),
+	}}
+	start := time.Now()
+	NewDispatcher(repo, server.Client()).dispatch(context.Background())
+	if len(repo.saved) != 3 {
+		t.Fatalf("saved %d attempts, want 3", len(repo.saved))
+	}
+
+	for i, attempts := range []int{1, 3} {
+		saved := repo.saved[i]
+		if saved.Status != models.DeliveryPending || saved.Attempts != attempts {
+			t.Errorf("status = %s after %d attempts, want pending after %d", saved.Status, saved.Attempts, attempts)
+		}
+		if saved.ResponseCode != http.StatusServiceUnavailable || saved.LastError == "" {
+			t.Errorf("attempt %d records code %d and error %q", attempts, saved.ResponseCode, saved.LastError)
+		}
+		backoff := baseBackoff << (attempts - 1)
+		if wait := saved.NextAttempt.Sub(start); wait < backoff || wait > backoff+time.Minute {
+			t.Errorf("attempt %d retries after %s, want %s", attempts, wait, backoff)
+		}
+	}
+
+	last := repo.saved[2]
+	if last.Status != models.DeliveryFailed || last.Attempts != maxAttempts {
+		t.Errorf("status = %s after %d attempts, want failed after %d", last.Status, last.Attempts, maxAttempts)
+	}
+}
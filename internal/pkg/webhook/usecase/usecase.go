@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/auth"
+	"github.com/DESOLATE17/Database-term-project/internal/pkg/webhook"
+	"github.com/mailru/easyjson"
+	"log"
+	"net/url"
+	"strings"
+)
+
+const maxDeliveriesLimit = 100
+
+var knownEvents = map[string]bool{
+	models.EventThreadCreated: true,
+	models.EventPostCreated:   true,
+	models.EventPostEdited:    true,
+	models.EventVoteChanged:   true,
+}
+
+type UseCase struct {
+	repo webhook.Repository
+}
+
+func NewWebhookUsecase(repo webhook.Repository) webhook.UseCase {
+	return &UseCase{repo: repo}
+}
+
+// canManage lets the forum owner and admins manage the forum's webhooks. It
+// returns the canonical slug of the forum.
+func (u *UseCase) canManage(ctx context.Context, slug string) (string, error) {
+	nickname, ok := auth.Caller(ctx)
+	if !ok {
+		return "", models.Unauthorized
+	}
+	slug, owner, err := u.repo.GetForumOwner(ctx, slug)
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(owner, nickname) {
+		return slug, nil
+	}
+	role, err := u.repo.GetUserRole(ctx, nickname)
+	if err != nil || role != models.RoleAdmin {
+		return "", models.Forbidden
+	}
+	return slug, nil
+}
+
+func (u *UseCase) CreateWebhook(ctx context.Context, hook models.Webhook) (models.Webhook, error) {
+	slug, err := u.canManage(ctx, hook.Forum)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	target, err := url.Parse(hook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return models.Webhook{}, models.Describe(models.BadRequest, models.ResourceWebhook, "", "Webhook needs an absolute http(s) url")
+	}
+	if err = webhook.CheckHost(ctx, target.Hostname()); err != nil {
+		return models.Webhook{}, models.Describe(models.BadRequest, models.ResourceWebhook, "", "Webhook host must resolve to public addresses only")
+	}
+	if hook.Events == nil {
+		hook.Events = []string{}
+	}
+	for _, event := range hook.Events {
+		if !knownEvents[event] {
+			return models.Webhook{}, models.Describe(models.BadRequest, models.ResourceWebhook, "", "Unknown event type "+event)
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err = rand.Read(secret); err != nil {
+		return models.Webhook{}, models.InternalError
+	}
+	hook.Forum = slug
+	hook.Secret = hex.EncodeToString(secret)
+	return u.repo.CreateWebhook(ctx, hook)
+}
+
+func (u *UseCase) GetWebhooks(ctx context.Context, slug string) ([]models.Webhook, error) {
+	slug, err := u.canManage(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.GetWebhooks(ctx, slug)
+}
+
+func (u *UseCase) DeleteWebhook(ctx context.Context, slug string, id int) error {
+	slug, err := u.canManage(ctx, slug)
+	if err != nil {
+		return err
+	}
+	return u.repo.DeleteWebhook(ctx, slug, id)
+}
+
+func (u *UseCase) GetDeliveries(ctx context.Context, slug string, id int, limit int) ([]models.WebhookDelivery, error) {
+	slug, err := u.canManage(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > maxDeliveriesLimit {
+		limit = maxDeliveriesLimit
+	}
+	return u.repo.GetDeliveries(ctx, slug, id, limit)
+}
+
+// Publish queues the event for the forum's webhooks; the dispatcher sends it.
+func (u *UseCase) Publish(event models.Event) {
+	if event.Forum == "" {
+		return
+	}
+	payload, err := easyjson.Marshal(event)
+	if err != nil {
+		return
+	}
+	if err = u.repo.EnqueueDeliveries(context.Background(), event, payload); err != nil {
+		log.Print("webhook: can't enqueue ", event.Type, " event: ", err)
+	}
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"encoding/base64"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/mailru/easyjson"
+)
+
+// EncodeCursor makes the opaque form of a page cursor handed out to clients.
+func EncodeCursor(cursor models.Cursor) string {
+	data, err := easyjson.Marshal(cursor)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(raw string) (models.Cursor, error) {
+	cursor := models.Cursor{}
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor, models.BadRequest
+	}
+	if err = easyjson.Unmarshal(data, &cursor); err != nil || cursor.Since == "" || cursor.Key == "" {
+		return models.Cursor{}, models.BadRequest
+	}
+	return cursor, nil
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"strings"
+)
+
+// Diff returns the changes turning old into new, split by lines or by words
+// depending on mode. Adjacent tokens with the same operation are merged.
+func Diff(old, new string, mode string) []models.DiffChange {
+	sep := "\n"
+	split := func(s string) []string { return strings.Split(s, "\n") }
+	if mode == models.DiffModeWord {
+		sep = " "
+		split = strings.Fields
+	}
+	d := differ{a: split(old), b: split(new)}
+	size := 2*((len(d.a)+len(d.b)+1)/2) + 3
+	d.forward, d.backward = make([]int, size), make([]int, size)
+	d.compare(0, len(d.a), 0, len(d.b))
+
+	changes := make([]models.DiffChange, 0, len(d.runs))
+	for _, run := range d.runs {
+		changes = append(changes, models.DiffChange{Op: run.op, Text: strings.Join(run.tokens, sep)})
+	}
+	return changes
+}
+
+// maxDiffSteps bounds the search for the middle snake. Past it the compared
+// ranges are reported as deleted and inserted as a whole, which keeps the diff
+// of long and very different messages linear in their length.
+const maxDiffSteps = 512
+
+// differ is Myers' diff in linear space: the middle snake of an edit script
+// splits the inputs in two halves that are compared on their own, so only
+// the two diagonal vectors are kept however large the messages get.
+type differ struct {
+	a, b              []string
+	forward, backward []int
+	runs              []diffRun
+}
+
+// diffRun is a change in the making, its tokens are joined once at the end.
+type diffRun struct {
+	op     string
+	tokens []string
+}
+
+func (d *differ) add(op, token string) {
+	if n := len(d.runs); n > 0 && d.runs[n-1].op == op {
+		d.runs[n-1].tokens = append(d.runs[n-1].tokens, token)
+		return
+	}
+	d.runs = append(d.runs, diffRun{op: op, tokens: []string{token}})
+}
+
+// compare emits the changes turning a[aLo:aHi] into b[bLo:bHi].
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.add(models.DiffEqual, d.a[aLo])
+		aLo++
+		bLo++
+	}
+	aEnd, bEnd := aHi, bHi
+	for aLo < aEnd && bLo < bEnd && d.a[aEnd-1] == d.b[bEnd-1] {
+		aEnd--
+		bEnd--
+	}
+
+	switch {
+	case aLo == aEnd:
+		for j := bLo; j < bEnd; j++ {
+			d.add(models.DiffInsert, d.b[j])
+		}
+	case bLo == bEnd:
+		for i := aLo; i < aEnd; i++ {
+			d.add(models.DiffDelete, d.a[i])
+		}
+	default:
+		if x, y, ok := d.middleSnake(aLo, aEnd, bLo, bEnd); ok {
+			d.compare(aLo, x, bLo, y)
+			d.compare(x, aEnd, y, bEnd)
+			break
+		}
+		for i := aLo; i < aEnd; i++ {
+			d.add(models.DiffDelete, d.a[i])
+		}
+		for j := bLo; j < bEnd; j++ {
+			d.add(models.DiffInsert, d.b[j])
+		}
+	}
+
+	for i := aEnd; i < aHi; i++ {
+		d.add(models.DiffEqual, d.a[i])
+	}
+}
+
+// middleSnake runs the forward and the backward search of a shortest edit
+// script at once and returns where they meet: the start of a snake lying on
+// that script. The ranges differ in their first and last tokens, so the point
+// is strictly inside them and both halves are smaller than the whole. It
+// gives up after maxDiffSteps.
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (int, int, bool) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	// Diagonal k of the forward search is delta-k of the backward one, which
+	// counts its x from the ends of the ranges.
+	off := len(d.forward) / 2
+	vf, vb := d.forward, d.backward
+	vf[off+1], vb[off+1] = 0, 0
+
+	for step := 0; step <= (n+m+1)/2 && step <= maxDiffSteps; step++ {
+		for k := -step; k <= step; k += 2 {
+			x := vf[off+k-1] + 1
+			if k == -step || (k != step && vf[off+k-1] < vf[off+k+1]) {
+				x = vf[off+k+1]
+			}
+			startX, startY := x, x-k
+			for y := x - k; x < n && y < m && d.a[aLo+x] == d.b[bLo+y]; y++ {
+				x++
+			}
+			vf[off+k] = x
+			if odd && delta-k >= -(step-1) && delta-k <= step-1 && x+vb[off+delta-k] >= n {
+				return aLo + startX, bLo + startY, true
+			}
+		}
+		for k := -step; k <= step; k += 2 {
+			x := vb[off+k-1] + 1
+			if k == -step || (k != step && vb[off+k-1] < vb[off+k+1]) {
+				x = vb[off+k+1]
+			}
+			for y := x - k; x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1]; y++ {
+				x++
+			}
+			vb[off+k] = x
+			if !odd && delta-k >= -step && delta-k <= step && x+vf[off+delta-k] >= n {
+				return aHi - x, bHi - (x - k), true
+			}
+		}
+	}
+	return 0, 0, false
+}
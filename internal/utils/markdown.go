@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"bytes"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"html"
+)
+
+// goldmark renders plain CommonMark; it already drops raw HTML, and the UGC
+// policy strips whatever else could run script or leave the page unsafely.
+var (
+	markdown  = goldmark.New()
+	sanitizer = newSanitizer()
+)
+
+func newSanitizer() *bluemonday.Policy {
+	policy := bluemonday.UGCPolicy()
+	policy.RequireNoFollowOnLinks(true)
+	policy.RequireNoReferrerOnLinks(true)
+	policy.AddTargetBlankToFullyQualifiedLinks(true)
+	return policy
+}
+
+// RenderMarkdown converts a CommonMark message to sanitized HTML.
+func RenderMarkdown(message string) string {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(message), &buf); err != nil {
+		return html.EscapeString(message)
+	}
+	return sanitizer.Sanitize(buf.String())
+}
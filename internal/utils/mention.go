@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// A mention is @nickname at the start of the message or after a character that
+// can't be part of a nickname, so e-mail addresses are not mistaken for mentions.
+var mentionRegexp = regexp.MustCompile(`(?:^|[^\w.@])@([\w.]+)`)
+
+// ParseMentions returns the nicknames mentioned in message, each once, in order
+// of appearance. They are not checked against the users table.
+func ParseMentions(message string) []string {
+	mentions := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range mentionRegexp.FindAllStringSubmatch(message, -1) {
+		nickname := strings.TrimRight(match[1], ".")
+		key := strings.ToLower(nickname)
+		if nickname == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		mentions = append(mentions, nickname)
+	}
+	return mentions
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/DESOLATE17/Database-term-project/internal/models"
+	"github.com/mailru/easyjson"
+	"net/http"
+)
+
+// Decode reads the JSON body of r into v; a missing or malformed body is a
+// validation error on the body field.
+func Decode(r *http.Request, v easyjson.Unmarshaler) error {
+	if err := easyjson.UnmarshalFromReader(r.Body, v); err != nil {
+		return models.InvalidField("body", "must be a valid JSON object")
+	}
+	return nil
+}
+
+// DecodeList reads a JSON array body of r into v.
+func DecodeList(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return models.InvalidField("body", "must be a valid JSON array")
+	}
+	return nil
+}
+
+// Invalid writes the 400 response of a validation error and reports whether
+// err was one.
+func Invalid(w http.ResponseWriter, err error) bool {
+	var invalid *models.ValidationError
+	if !errors.As(err, &invalid) {
+		return false
+	}
+	Error(w, err)
+	return true
+}
@@ -2,7 +2,7 @@ package utils
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/DESOLATE17/Database-term-project/internal/models"
 	"net/http"
 )
@@ -12,14 +12,6 @@ func Response(w http.ResponseWriter, status int, body interface{}) {
 		w.Header().Set("Content-Type", "application/json")
 	}
 	w.WriteHeader(status)
-	if status == http.StatusNotFound && body != nil {
-		jsn, err := json.Marshal(models.ErrorResponse{Message: fmt.Sprintf("Can't find user with id #%s\\n", body)})
-		if err != nil {
-			return
-		}
-		_, _ = w.Write(jsn)
-		return
-	}
 	if body != nil {
 		jsn, err := json.Marshal(body)
 		if err != nil {
@@ -28,3 +20,50 @@ func Response(w http.ResponseWriter, status int, body interface{}) {
 		_, _ = w.Write(jsn)
 	}
 }
+
+// errorKinds maps the sentinel errors to their status, code and default message.
+var errorKinds = []struct {
+	err     error
+	status  int
+	code    string
+	message string
+}{
+	{models.BadRequest, http.StatusBadRequest, models.CodeBadRequest, "Invalid request"},
+	{models.Unauthorized, http.StatusUnauthorized, models.CodeUnauthorized, "Authorization required"},
+	{models.Forbidden, http.StatusForbidden, models.CodeForbidden, "Not allowed for this user"},
+	{models.ThreadClosed, http.StatusForbidden, models.CodeThreadClosed, "Thread is closed"},
+	{models.NotFound, http.StatusNotFound, models.CodeNotFound, "Not found"},
+	{models.Conflict, http.StatusConflict, models.CodeConflict, "Conflict"},
+}
+
+// Error writes the response of a use case error: the status and code of the
+// sentinel it wraps, with the resource, message and details of a *models.Error
+// and the field errors of a *models.ValidationError. Anything else is a 500.
+func Error(w http.ResponseWriter, err error) {
+	status, body := http.StatusInternalServerError, models.ErrorResponse{Code: models.CodeInternalError, Message: "Internal error"}
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind.err) {
+			status, body = kind.status, models.ErrorResponse{Code: kind.code, Message: kind.message}
+			break
+		}
+	}
+
+	var typed *models.Error
+	var invalid *models.ValidationError
+	switch {
+	case errors.As(err, &invalid):
+		body.Message = invalid.Message
+		body.Details = invalid.Errors
+	case errors.As(err, &typed):
+		body.Resource, body.ID, body.Details = typed.Resource, typed.ID, typed.Details
+		switch {
+		case typed.Message != "":
+			body.Message = typed.Message
+		case status == http.StatusNotFound:
+			body.Message = "Can't find " + typed.Resource + " " + typed.ID
+		case status == http.StatusConflict:
+			body.Message = "Conflicts with the existing " + typed.Resource + " " + typed.ID
+		}
+	}
+	Response(w, status, body)
+}